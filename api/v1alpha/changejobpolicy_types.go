@@ -0,0 +1,95 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ChangeJobPolicySpec defines the restrictions on ChangeTriggeredJobs in the selected namespaces
+type ChangeJobPolicySpec struct {
+	// Optional: namespaces the policy applies to, selects all namespaces when unset
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Optional: kinds that may be watched, all kinds are allowed when empty
+	// +optional
+	AllowedKinds []PolicyGroupKind `json:"allowedKinds,omitempty"`
+
+	// Optional: allow watching resources in other namespaces and cluster-scoped resources
+	// +optional
+	// +kubebuilder:default=true
+	AllowCrossNamespace *bool `json:"allowCrossNamespace,omitempty"`
+
+	// Optional: maximum number of watched resources per ChangeTriggeredJob
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxResources *int32 `json:"maxResources,omitempty"`
+
+	// Optional: minimum cooldown between triggered jobs
+	// +optional
+	MinCooldown *metav1.Duration `json:"minCooldown,omitempty"`
+
+	// Optional: maximum number of jobs kept in history
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxHistory *int32 `json:"maxHistory,omitempty"`
+}
+
+// PolicyGroupKind matches the kinds of watched resources
+type PolicyGroupKind struct {
+	// API group, empty for the core group, * for any group
+	// +optional
+	Group string `json:"group"`
+
+	// Kind, e.g., ConfigMap, * for any kind in the group
+	// +required
+	Kind string `json:"kind"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=cjp
+
+// ChangeJobPolicy is the Schema for the changejobpolicies API
+type ChangeJobPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the restrictions enforced by the policy
+	// +required
+	Spec ChangeJobPolicySpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// ChangeJobPolicyList contains a list of ChangeJobPolicy
+type ChangeJobPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []ChangeJobPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(func(s *runtime.Scheme) error {
+		s.AddKnownTypes(SchemeGroupVersion, &ChangeJobPolicy{}, &ChangeJobPolicyList{})
+		return nil
+	})
+}
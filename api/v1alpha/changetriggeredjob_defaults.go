@@ -0,0 +1,37 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha
+
+// SetSchemaDefaults sets the CRD schema defaults of the nested fields on unset fields: the watched fields of every
+// resource, the event sink mode and the rate limit policy. The condition, cooldown and history are defaulted by the
+// mutating webhook from the controller configuration instead, see ChangeTriggeredJobCustomDefaulter.DefaultSpec.
+// Clients reading manifests without a cluster, such as the kubectl plugin, apply both to work on the same object the
+// controller reconciles.
+func SetSchemaDefaults(obj *ChangeTriggeredJob) {
+	spec := &obj.Spec
+	for i := range spec.Resources {
+		if spec.Resources[i].Fields == nil {
+			spec.Resources[i].Fields = []string{"*"}
+		}
+	}
+	if spec.EventSink != nil && spec.EventSink.Mode == "" {
+		spec.EventSink.Mode = EventSinkModeBinary
+	}
+	if spec.RateLimit != nil && spec.RateLimit.Policy == "" {
+		spec.RateLimit.Policy = RateLimitPolicyDrop
+	}
+}
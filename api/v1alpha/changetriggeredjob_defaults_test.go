@@ -0,0 +1,122 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+// applySchemaDefaults sets the defaults of schema on the fields missing or null in obj, as the API server does
+func applySchemaDefaults(obj any, schema map[string]any) {
+	switch value := obj.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for name, property := range properties {
+			property := property.(map[string]any)
+			if value[name] == nil {
+				if def, ok := property["default"]; ok {
+					value[name] = def
+				}
+			}
+			applySchemaDefaults(value[name], property)
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for _, item := range value {
+				applySchemaDefaults(item, items)
+			}
+		}
+	}
+}
+
+func TestSetSchemaDefaultsMatchesCRD(t *testing.T) {
+	data, err := os.ReadFile("../../config/crd/bases/triggers.changejob.dev_changetriggeredjobs.yaml")
+	if err != nil {
+		t.Fatalf("Expected to read the CRD, got %v", err)
+	}
+	var crd struct {
+		Spec struct {
+			Versions []struct {
+				Schema struct {
+					OpenAPIV3Schema map[string]any `json:"openAPIV3Schema"`
+				} `json:"schema"`
+			} `json:"versions"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		t.Fatalf("Expected to parse the CRD, got %v", err)
+	}
+
+	obj := &ChangeTriggeredJob{
+		ObjectMeta: metav1.ObjectMeta{Name: testCTJName, Namespace: testNamespace},
+		Spec: ChangeTriggeredJobSpec{
+			Resources: []ResourceReference{
+				{APIVersion: "v1", Kind: testKindConfigMap, Name: testConfigName, Namespace: testNamespace},
+				{APIVersion: "v1", Kind: testKindConfigMap, Name: "other", Namespace: testNamespace, Fields: []string{testFieldKey1}},
+			},
+			EventSink: &EventSink{URL: "http://sink"},
+			RateLimit: &TriggerRateLimit{MaxTriggers: 1, Window: metav1.Duration{Duration: time.Hour}},
+			// Defaulted by the mutating webhook from the controller configuration, not by SetSchemaDefaults
+			Condition: ptr.To(TriggerConditionAll),
+			Cooldown:  &metav1.Duration{Duration: time.Minute},
+			History:   ptr.To(int32(3)),
+		},
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var admitted map[string]any
+	if err := json.Unmarshal(raw, &admitted); err != nil {
+		t.Fatal(err)
+	}
+	applySchemaDefaults(admitted, crd.Spec.Versions[0].Schema.OpenAPIV3Schema)
+	raw, err = json.Marshal(admitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &ChangeTriggeredJob{}
+	if err := json.Unmarshal(raw, expected); err != nil {
+		t.Fatal(err)
+	}
+
+	SetSchemaDefaults(obj)
+	got, err := json.Marshal(obj.Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(expected.Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Expected the CRD schema defaults\n%s\ngot\n%s", want, got)
+	}
+
+	fields := obj.Spec.Resources[1].Fields
+	if len(fields) != 1 || fields[0] != testFieldKey1 {
+		t.Errorf("Expected set fields to be kept, got %v", fields)
+	}
+}
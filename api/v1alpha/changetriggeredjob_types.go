@@ -40,23 +40,176 @@ type ChangeTriggeredJobSpec struct {
 	// +required
 	Resources []ResourceReference `json:"resources"`
 
-	// Trigger condition, job triggers when All or Any watched resource changes
+	// Trigger condition, job triggers when All or Any watched resource changes, AtLeast minChanged of them, or when
+	// the Expression over their IDs is true
 	// +optional
 	// +default:value="Any"
 	Condition *TriggerCondition `json:"condition"`
 
+	// Optional: with the AtLeast condition, minimum total weight of the changed resources that triggers a job
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinChanged *int32 `json:"minChanged,omitempty"`
+
+	// Optional: with the Expression condition, boolean expression over the IDs of the watched resources, true for
+	// the resources that changed, e.g., `(dbConfig && dbSecret) || featureFlags`
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	Expression string `json:"expression,omitempty"`
+
+	// Optional: with the All condition, changes accumulated since the last job expire after this window,
+	// defaults to keeping them until a job triggers
+	// +optional
+	ChangeWindow *metav1.Duration `json:"changeWindow,omitempty"`
+
 	// Optional: cooldown period between triggers
 	// +optional
 	// +default:value="60s"
 	Cooldown *metav1.Duration `json:"cooldown,omitempty"`
 
+	// Optional: how often watched resources are polled, clamped to the controller's minimum and maximum poll interval,
+	// defaults to the controller poll interval
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+
 	// Optional: max job history to keep
 	// +optional
 	// +default:value=5
 	// +kubebuilder:validation:Minimum=1
 	History *int32 `json:"history,omitempty"`
+
+	// Optional: ServiceAccount in the ChangeTriggeredJob namespace impersonated to poll resources and create jobs,
+	// defaults to the controller's own identity
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Optional: CloudEvents sink notified of detected changes and job outcomes,
+	// overrides the controller-wide sink
+	// +optional
+	EventSink *EventSink `json:"eventSink,omitempty"`
+
+	// Optional: stop triggering jobs on changes, watched resources are still polled so changes made while suspended
+	// do not trigger on resume
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+
+	// Optional: jobs triggered by changes only run within one of these windows, changes outside them are held pending
+	// until the next window opens
+	// +optional
+	TriggerWindows []TriggerWindow `json:"triggerWindows,omitempty"`
+
+	// Optional: cron schedule jobs also run on when nothing changed, e.g., "0 3 * * *" or "@daily", in UTC unless
+	// prefixed with CRON_TZ=<time zone>
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// Optional: deadline in seconds for starting a scheduled run that was missed, e.g., while the controller was down,
+	// runs missed for longer are skipped; defaults to no deadline
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	StartingDeadlineSeconds *int64 `json:"startingDeadlineSeconds,omitempty"`
+
+	// Optional: at most maxTriggers jobs are triggered by changes within a rolling window, protecting downstream
+	// systems from flapping resources
+	// +optional
+	RateLimit *TriggerRateLimit `json:"rateLimit,omitempty"`
+
+	// Optional: detects watched fields that change on nearly every poll, raising the Flapping condition and
+	// optionally suspending triggering until acknowledged
+	// +optional
+	FlappingDetection *FlappingDetection `json:"flappingDetection,omitempty"`
 }
 
+// Detection of watched fields that keep changing
+type FlappingDetection struct {
+	// Number of changes of a watched field within the window that make it flapping
+	// +required
+	// +kubebuilder:validation:Minimum=2
+	Threshold int32 `json:"threshold"`
+
+	// Rolling window the changes are counted in, e.g., 10m
+	// +required
+	Window metav1.Duration `json:"window"`
+
+	// Optional: suspend triggering when a field flaps, until the flapping-acknowledged-at annotation is set to a
+	// new value
+	// +optional
+	AutoSuspend bool `json:"autoSuspend,omitempty"`
+}
+
+// Budget of jobs triggered within a rolling window
+type TriggerRateLimit struct {
+	// Maximum number of jobs triggered within the window, including scheduled and requested ones
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	MaxTriggers int32 `json:"maxTriggers"`
+
+	// Rolling window the jobs are counted in, e.g., 1h
+	// +required
+	Window metav1.Duration `json:"window"`
+
+	// Optional: what happens to changes while the budget is exhausted, Drop discards them and Queue holds a pending
+	// job until the budget allows it, defaults to Drop
+	// +optional
+	// +default:value="Drop"
+	Policy RateLimitPolicy `json:"policy,omitempty"`
+}
+
+// Define what happens to changes beyond the rate limit
+// +kubebuilder:validation:Enum:=Drop;Queue
+type RateLimitPolicy string
+
+const (
+	RateLimitPolicyDrop  RateLimitPolicy = "Drop"
+	RateLimitPolicyQueue RateLimitPolicy = "Queue"
+)
+
+// Recurring time range jobs may be triggered in
+type TriggerWindow struct {
+	// Optional: days of the week the window opens on, defaults to every day
+	// +optional
+	Days []Weekday `json:"days,omitempty"`
+
+	// Time the window opens, HH:MM
+	// +required
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+
+	// Time the window closes, HH:MM, on the next day when not after start
+	// +required
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+
+	// Optional: IANA time zone of start and end, e.g., Europe/Berlin, defaults to UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// Define days of the week
+// +kubebuilder:validation:Enum:=Mon;Tue;Wed;Thu;Fri;Sat;Sun
+type Weekday string
+
+// CloudEvents sink
+type EventSink struct {
+	// HTTP(S) endpoint receiving the events
+	// +required
+	URL string `json:"url"`
+
+	// CloudEvents content mode used to deliver events
+	// +optional
+	// +default:value="binary"
+	Mode EventSinkMode `json:"mode,omitempty"`
+}
+
+// Define CloudEvents content modes
+// +kubebuilder:validation:Enum:=binary;structured
+type EventSinkMode string
+
+const (
+	EventSinkModeBinary     EventSinkMode = "binary"
+	EventSinkModeStructured EventSinkMode = "structured"
+)
+
 // Watched Resource object
 type ResourceReference struct {
 	// API group of the resource, e.g., apps/v1, example.io/v1beta
@@ -79,15 +232,110 @@ type ResourceReference struct {
 	// +optional
 	// +kubebuilder:default={"*"}
 	Fields []string `json:"fields,omitempty"`
+
+	// Optional: with All, the resource only counts as changed when every watched field changed, defaults to Any
+	// +optional
+	FieldCondition *FieldCondition `json:"fieldCondition,omitempty"`
+
+	// Optional: fields rendered into the job as environment variables, their changes never trigger a job
+	// +optional
+	ContextFields []ContextField `json:"contextFields,omitempty"`
+
+	// Optional: identifier of the resource in the trigger expression
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	ID string `json:"id,omitempty"`
+
+	// Optional: weight of the resource towards minChanged of the AtLeast condition, defaults to 1
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Weight *int32 `json:"weight,omitempty"`
+
+	// Optional: a job only triggers when this resource changed, whatever the condition
+	// +optional
+	Required bool `json:"required,omitempty"`
 }
 
-// Define trigger conditions
+// Field of a watched resource rendered into the job
+type ContextField struct {
+	// JSON Path of the field within the resource
+	// +required
+	Field string `json:"field"`
+
+	// Name of the environment variable set to the field value in every container of the job
+	// +required
+	Env string `json:"env"`
+}
+
+// Define field conditions
 // +kubebuilder:validation:Enum:=All;Any
+type FieldCondition string
+
+const (
+	FieldConditionAll FieldCondition = "All"
+	FieldConditionAny FieldCondition = "Any"
+)
+
+// Define trigger conditions
+// +kubebuilder:validation:Enum:=All;Any;AtLeast;Expression
 type TriggerCondition string
 
 const (
-	TriggerConditionAll TriggerCondition = "All"
-	TriggerConditionAny TriggerCondition = "Any"
+	TriggerConditionAll        TriggerCondition = "All"
+	TriggerConditionAny        TriggerCondition = "Any"
+	TriggerConditionAtLeast    TriggerCondition = "AtLeast"
+	TriggerConditionExpression TriggerCondition = "Expression"
+)
+
+// TriggerRequestedAtAnnotation requests a job run regardless of changes, cooldown and suspension when set to a new
+// value, e.g., the current time
+const TriggerRequestedAtAnnotation = "changejob.dev/trigger-requested-at"
+
+// FlappingAcknowledgedAtAnnotation resumes triggering after flapping suspended it when set to a new value, e.g., the
+// current time
+const FlappingAcknowledgedAtAnnotation = "changejob.dev/flapping-acknowledged-at"
+
+// Annotations recording on a Job what triggered it and why
+const (
+	TriggeredByAnnotation   = "changejob.dev/triggered-by"
+	TriggerReasonAnnotation = "changejob.dev/trigger-reason"
+)
+
+// Define what triggered a job
+// +kubebuilder:validation:Enum:=Changed;Scheduled;Requested
+type TriggeredBy string
+
+const (
+	TriggeredByChanged   TriggeredBy = "Changed"
+	TriggeredByScheduled TriggeredBy = "Scheduled"
+	TriggeredByRequested TriggeredBy = "Requested"
+)
+
+// Condition types
+const (
+	ConditionTypeDegraded    = "Degraded"
+	ConditionTypeRateLimited = "RateLimited"
+	ConditionTypeFlapping    = "Flapping"
+	// ChangeJobPolicies skipped for an invalid namespaceSelector
+	ConditionTypePoliciesSkipped = "PoliciesSkipped"
+)
+
+// Condition reasons
+const (
+	ReasonReconciled               = "Reconciled"
+	ReasonPermissionDenied         = "PermissionDenied"
+	ReasonInvalidJobTemplate       = "InvalidJobTemplate"
+	ReasonPolicyViolation          = "PolicyViolation"
+	ReasonInvalidTriggerExpression = "InvalidTriggerExpression"
+	ReasonInvalidTriggerWindows    = "InvalidTriggerWindows"
+	ReasonInvalidSchedule          = "InvalidSchedule"
+	ReasonInvalidRateLimit         = "InvalidRateLimit"
+	ReasonWithinBudget             = "WithinBudget"
+	ReasonBudgetExhausted          = "BudgetExhausted"
+	ReasonStable                   = "Stable"
+	ReasonFieldFlapping            = "FieldFlapping"
+	ReasonAutoSuspended            = "AutoSuspended"
+	ReasonInvalidPolicy            = "InvalidPolicy"
 )
 
 // ChangeTriggeredJobStatus defines the observed state of ChangeTriggeredJob.
@@ -127,6 +375,46 @@ type ChangeTriggeredJobStatus struct {
 	// Last Job status
 	// +optional
 	LastJobStatus JobState `json:"lastJobStatus,omitempty"`
+
+	// Value of the trigger-requested-at annotation last handled
+	// +optional
+	LastHandledTriggerRequest string `json:"lastHandledTriggerRequest,omitempty"`
+
+	// Why the last job was triggered, e.g., the expression clause that fired
+	// +optional
+	LastTriggerReason string `json:"lastTriggerReason,omitempty"`
+
+	// What triggered the last job, Changed, Scheduled or Requested
+	// +optional
+	LastTriggeredBy TriggeredBy `json:"lastTriggeredBy,omitempty"`
+
+	// Last scheduled run time handled, whether the job ran or the run was skipped
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Next scheduled run time
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// Triggering is suspended after a watched field flapped, until acknowledged
+	// +optional
+	AutoSuspended bool `json:"autoSuspended,omitempty"`
+
+	// Value of the flapping-acknowledged-at annotation last handled
+	// +optional
+	LastHandledFlappingAcknowledgement string `json:"lastHandledFlappingAcknowledgement,omitempty"`
+
+	// Times of the jobs triggered within the rate limit window
+	// +optional
+	TriggerHistory []metav1.Time `json:"triggerHistory,omitempty"`
+
+	// Why a job is pending, held until the next trigger window opens or the rate limit allows it
+	// +optional
+	PendingTriggerReason string `json:"pendingTriggerReason,omitempty"`
+
+	// Time a pending job may trigger next, when the next trigger window opens or the rate limit allows it
+	// +optional
+	NextEligibleTime *metav1.Time `json:"nextEligibleTime,omitempty"`
 }
 
 // Watched ResourceHash object
@@ -150,11 +438,31 @@ type ResourceReferenceStatus struct {
 	// Optional: fields to watch within the resource
 	// +optional
 	Fields []ResourceFieldHash `json:"fields,omitempty"`
+
+	// Optional: ID of the HMAC key the field hashes were computed with, empty for plain SHA256
+	// +optional
+	KeyID string `json:"keyID,omitempty"`
+
+	// resourceVersion of the resource the field hashes were computed from, the hashes are reused while it is unchanged
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// Generation of the resource the field hashes were computed from
+	// +optional
+	Generation int64 `json:"generation,omitempty"`
+
+	// Time of the last change since the last job, while the All condition accumulates changes
+	// +optional
+	ChangedAt *metav1.Time `json:"changedAt,omitempty"`
 }
 
 type ResourceFieldHash struct {
 	Field    string `json:"field"`
 	LastHash string `json:"hash"`
+
+	// Times the field changed within the flapping detection window
+	// +optional
+	RecentChanges []metav1.Time `json:"recentChanges,omitempty"`
 }
 
 // Define last job state
@@ -183,13 +183,19 @@ func TestTriggerConditionEnum(t *testing.T) {
 			condition: TriggerCondition("Any"),
 			valid:     true,
 		},
+		{
+			name:      "valid AtLeast condition",
+			condition: TriggerConditionAtLeast,
+			valid:     true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			validConditions := map[TriggerCondition]bool{
-				TriggerConditionAll: true,
-				TriggerConditionAny: true,
+				TriggerConditionAll:     true,
+				TriggerConditionAny:     true,
+				TriggerConditionAtLeast: true,
 			}
 
 			if _, ok := validConditions[tt.condition]; !ok && tt.valid {
@@ -9,6 +9,109 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeJobPolicy) DeepCopyInto(out *ChangeJobPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeJobPolicy.
+func (in *ChangeJobPolicy) DeepCopy() *ChangeJobPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeJobPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChangeJobPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeJobPolicyList) DeepCopyInto(out *ChangeJobPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ChangeJobPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeJobPolicyList.
+func (in *ChangeJobPolicyList) DeepCopy() *ChangeJobPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeJobPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ChangeJobPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeJobPolicySpec) DeepCopyInto(out *ChangeJobPolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedKinds != nil {
+		in, out := &in.AllowedKinds, &out.AllowedKinds
+		*out = make([]PolicyGroupKind, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowCrossNamespace != nil {
+		in, out := &in.AllowCrossNamespace, &out.AllowCrossNamespace
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaxResources != nil {
+		in, out := &in.MaxResources, &out.MaxResources
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinCooldown != nil {
+		in, out := &in.MinCooldown, &out.MinCooldown
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxHistory != nil {
+		in, out := &in.MaxHistory, &out.MaxHistory
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeJobPolicySpec.
+func (in *ChangeJobPolicySpec) DeepCopy() *ChangeJobPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeJobPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ChangeTriggeredJob) DeepCopyInto(out *ChangeTriggeredJob) {
 	*out = *in
@@ -84,16 +187,63 @@ func (in *ChangeTriggeredJobSpec) DeepCopyInto(out *ChangeTriggeredJobSpec) {
 		*out = new(TriggerCondition)
 		**out = **in
 	}
+	if in.MinChanged != nil {
+		in, out := &in.MinChanged, &out.MinChanged
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ChangeWindow != nil {
+		in, out := &in.ChangeWindow, &out.ChangeWindow
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.Cooldown != nil {
 		in, out := &in.Cooldown, &out.Cooldown
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.History != nil {
 		in, out := &in.History, &out.History
 		*out = new(int32)
 		**out = **in
 	}
+	if in.EventSink != nil {
+		in, out := &in.EventSink, &out.EventSink
+		*out = new(EventSink)
+		**out = **in
+	}
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TriggerWindows != nil {
+		in, out := &in.TriggerWindows, &out.TriggerWindows
+		*out = make([]TriggerWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartingDeadlineSeconds != nil {
+		in, out := &in.StartingDeadlineSeconds, &out.StartingDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(TriggerRateLimit)
+		**out = **in
+	}
+	if in.FlappingDetection != nil {
+		in, out := &in.FlappingDetection, &out.FlappingDetection
+		*out = new(FlappingDetection)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeTriggeredJobSpec.
@@ -127,6 +277,25 @@ func (in *ChangeTriggeredJobStatus) DeepCopyInto(out *ChangeTriggeredJobStatus)
 		in, out := &in.LastTriggeredTime, &out.LastTriggeredTime
 		*out = (*in).DeepCopy()
 	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.TriggerHistory != nil {
+		in, out := &in.TriggerHistory, &out.TriggerHistory
+		*out = make([]v1.Time, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NextEligibleTime != nil {
+		in, out := &in.NextEligibleTime, &out.NextEligibleTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeTriggeredJobStatus.
@@ -139,9 +308,77 @@ func (in *ChangeTriggeredJobStatus) DeepCopy() *ChangeTriggeredJobStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContextField) DeepCopyInto(out *ContextField) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContextField.
+func (in *ContextField) DeepCopy() *ContextField {
+	if in == nil {
+		return nil
+	}
+	out := new(ContextField)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventSink) DeepCopyInto(out *EventSink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventSink.
+func (in *EventSink) DeepCopy() *EventSink {
+	if in == nil {
+		return nil
+	}
+	out := new(EventSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlappingDetection) DeepCopyInto(out *FlappingDetection) {
+	*out = *in
+	out.Window = in.Window
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlappingDetection.
+func (in *FlappingDetection) DeepCopy() *FlappingDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(FlappingDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyGroupKind) DeepCopyInto(out *PolicyGroupKind) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyGroupKind.
+func (in *PolicyGroupKind) DeepCopy() *PolicyGroupKind {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyGroupKind)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceFieldHash) DeepCopyInto(out *ResourceFieldHash) {
 	*out = *in
+	if in.RecentChanges != nil {
+		in, out := &in.RecentChanges, &out.RecentChanges
+		*out = make([]v1.Time, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceFieldHash.
@@ -162,6 +399,21 @@ func (in *ResourceReference) DeepCopyInto(out *ResourceReference) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.FieldCondition != nil {
+		in, out := &in.FieldCondition, &out.FieldCondition
+		*out = new(FieldCondition)
+		**out = **in
+	}
+	if in.ContextFields != nil {
+		in, out := &in.ContextFields, &out.ContextFields
+		*out = make([]ContextField, len(*in))
+		copy(*out, *in)
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceReference.
@@ -180,7 +432,13 @@ func (in *ResourceReferenceStatus) DeepCopyInto(out *ResourceReferenceStatus) {
 	if in.Fields != nil {
 		in, out := &in.Fields, &out.Fields
 		*out = make([]ResourceFieldHash, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ChangedAt != nil {
+		in, out := &in.ChangedAt, &out.ChangedAt
+		*out = (*in).DeepCopy()
 	}
 }
 
@@ -193,3 +451,39 @@ func (in *ResourceReferenceStatus) DeepCopy() *ResourceReferenceStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TriggerRateLimit) DeepCopyInto(out *TriggerRateLimit) {
+	*out = *in
+	out.Window = in.Window
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerRateLimit.
+func (in *TriggerRateLimit) DeepCopy() *TriggerRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(TriggerRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TriggerWindow) DeepCopyInto(out *TriggerWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]Weekday, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerWindow.
+func (in *TriggerWindow) DeepCopy() *TriggerWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(TriggerWindow)
+	in.DeepCopyInto(out)
+	return out
+}
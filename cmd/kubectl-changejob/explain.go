@@ -0,0 +1,267 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/controller"
+)
+
+func newExplainCommand(o *options) *cobra.Command {
+	var hashKeySecret string
+	cmd := &cobra.Command{
+		Use:   "explain NAME",
+		Short: "Explain the last poll and whether the next poll would trigger a job",
+		Long: `Explain shows the outcome of the last poll, then polls the watched resources with the same field
+extraction and hashing as the controller and compares them with the hashes in status, so it shows what
+the next poll would detect and whether it would trigger a job.
+
+Resources are read with your own credentials. Hashes keyed with the controller's HMAC key can only be
+compared with --hash-key-secret.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, changeJob, err := o.get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			var hashKey *controller.HashKey
+			if hashKeySecret != "" {
+				namespace, name, ok := strings.Cut(hashKeySecret, "/")
+				if !ok || namespace == "" || name == "" {
+					return fmt.Errorf("invalid hash key secret %q, must be namespace/name", hashKeySecret)
+				}
+				provider := &controller.HashKeyProvider{Reader: c, Secret: types.NamespacedName{Namespace: namespace, Name: name}}
+				if hashKey, err = provider.Key(cmd.Context()); err != nil {
+					return err
+				}
+			}
+			o.explain(cmd.Context(), c, changeJob, hashKey)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&hashKeySecret, "hash-key-secret", "", "Secret holding the controller's HMAC key as namespace/name")
+	return cmd
+}
+
+func (o *options) explain(ctx context.Context, c client.Client, changeJob *triggersv1alpha.ChangeTriggeredJob, hashKey *controller.HashKey) {
+	condition := describeCondition(&changeJob.Spec)
+	fmt.Fprintf(o.out, "ChangeTriggeredJob %s/%s watches %d resources, condition %s\n\n", changeJob.Namespace, changeJob.Name, len(changeJob.Spec.Resources), condition)
+
+	// Last poll
+	if degraded := meta.FindStatusCondition(changeJob.Status.Conditions, triggersv1alpha.ConditionTypeDegraded); degraded == nil {
+		fmt.Fprintln(o.out, "Last poll: not reconciled yet")
+	} else if degraded.Status == metav1.ConditionTrue {
+		fmt.Fprintf(o.out, "Last poll: failed %s ago, %s: %s\n", o.age(degraded.LastTransitionTime.Time), degraded.Reason, degraded.Message)
+	} else {
+		fmt.Fprintf(o.out, "Last poll: %s\n", degraded.Message)
+	}
+	fmt.Fprintf(o.out, "Last job: %s, triggered %s\n\n", lastJob(changeJob), o.since(changeJob.Status.LastTriggeredTime))
+
+	// Poll the resources the way the controller does
+	keyed := map[string]string{}
+	hashAll := false
+	for _, status := range changeJob.Status.ResourceHashes {
+		if status.KeyID != "" {
+			keyed[resourceName(status.APIVersion, status.Kind, status.Namespace, status.Name)] = status.KeyID
+			hashAll = hashAll || !(status.APIVersion == "v1" && status.Kind == "Secret")
+		}
+	}
+	poller := controller.Poller{Client: c, HashKey: hashKey, HashAll: hashAll}
+	current := make([]triggersv1alpha.ResourceReferenceStatus, 0, len(changeJob.Spec.Resources))
+	incomplete := false
+	fmt.Fprintln(o.out, "Changes since the last poll:")
+	for _, ref := range changeJob.Spec.Resources {
+		name := resourceName(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+		if keyID, ok := keyed[name]; ok && (hashKey == nil || hashKey.ID != keyID) {
+			fmt.Fprintf(o.out, "  %s: hashed with key %s, pass --hash-key-secret with that key to compare\n", name, keyID)
+			incomplete = true
+			continue
+		}
+		result, err := poller.Poll(ctx, ref)
+		if err != nil {
+			fmt.Fprintf(o.out, "  %s: unable to poll: %v\n", name, err)
+			incomplete = true
+			continue
+		}
+		current = append(current, result)
+	}
+
+	changes := controller.DetectChanges(changeJob.Spec.Resources, changeJob.Status.ResourceHashes, current)
+	if controller.AccumulatesChanges(&changeJob.Spec) && changeJob.Status.ResourceHashes != nil {
+		var window time.Duration
+		if changeJob.Spec.ChangeWindow != nil {
+			window = changeJob.Spec.ChangeWindow.Duration
+		}
+		changes = controller.AccumulateChanges(changeJob.Spec.Resources, changeJob.Status.ResourceHashes, current, changes, window, o.now())
+	}
+	o.printChanges(changeJob.Status.ResourceHashes, current, changes)
+	fmt.Fprintln(o.out)
+
+	verdict, _ := o.verdict(changeJob, changes, incomplete)
+	fmt.Fprintf(o.out, "Next poll: %s\n", verdict)
+}
+
+// printChanges lists the changed fields of every polled resource, the fields that changed without meeting the All
+// field condition, and the earlier changes accumulated since the last job
+func (o *options) printChanges(last, current []triggersv1alpha.ResourceReferenceStatus, changes []controller.ResourceChange) {
+	changed := make(map[string][]string, len(changes))
+	for _, change := range changes {
+		ref := change.Resource
+		changed[resourceName(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)] = change.Fields
+	}
+	lastStatuses := make(map[string]triggersv1alpha.ResourceReferenceStatus, len(last))
+	for _, status := range last {
+		lastStatuses[resourceName(status.APIVersion, status.Kind, status.Namespace, status.Name)] = status
+	}
+	for _, status := range current {
+		name := resourceName(status.APIVersion, status.Kind, status.Namespace, status.Name)
+		before, ok := lastStatuses[name]
+		if fields := changed[name]; len(fields) > 0 {
+			fmt.Fprintf(o.out, "  %s: %s changed\n", name, strings.Join(fields, ", "))
+		} else if fields := controller.ChangedFields(before.Fields, status.Fields); ok && before.KeyID == status.KeyID && len(fields) > 0 {
+			fmt.Fprintf(o.out, "  %s: only %s changed, field condition All is not met\n", name, strings.Join(fields, ", "))
+		} else if status.ChangedAt != nil {
+			fmt.Fprintf(o.out, "  %s: no changes, changed %s ago since the last job\n", name, o.age(status.ChangedAt.Time))
+		} else {
+			fmt.Fprintf(o.out, "  %s: no changes\n", name)
+		}
+	}
+}
+
+// verdict explains whether the next poll triggers a job and reports if it does, checking in the order of the reconciler
+func (o *options) verdict(changeJob *triggersv1alpha.ChangeTriggeredJob, changes []controller.ResourceChange, incomplete bool) (string, bool) {
+	verdict, triggered := o.changeVerdict(changeJob, changes, incomplete)
+	if triggered || changeJob.Spec.Schedule == "" || ptr.Deref(changeJob.Spec.Suspend, false) || changeJob.Status.AutoSuspended {
+		return verdict, triggered
+	}
+	// A due scheduled run triggers a job when the changes do not
+	schedule, err := controller.ParseSchedule(changeJob.Spec.Schedule)
+	if err != nil {
+		return fmt.Sprintf("invalid schedule: %v", err), false
+	}
+	last := changeJob.CreationTimestamp.Time
+	if changeJob.Status.LastScheduleTime != nil {
+		last = changeJob.Status.LastScheduleTime.Time
+	}
+	if last.IsZero() {
+		return verdict, false
+	}
+	if due, _ := controller.DueSchedule(schedule, last, changeJob.Spec.StartingDeadlineSeconds, o.now()); !due.IsZero() {
+		return fmt.Sprintf("triggers the scheduled run at %s, %s", due.UTC().Format(time.RFC3339), verdict), true
+	}
+	return verdict, false
+}
+
+// changeVerdict explains whether the changes, a trigger request or a pending job trigger a job
+func (o *options) changeVerdict(changeJob *triggersv1alpha.ChangeTriggeredJob, changes []controller.ResourceChange, incomplete bool) (string, bool) {
+	requested := changeJob.Annotations[triggersv1alpha.TriggerRequestedAtAnnotation]
+	if requested != "" && requested != changeJob.Status.LastHandledTriggerRequest {
+		return fmt.Sprintf("triggers a job, requested at %s", requested), true
+	}
+	if changeJob.Status.ResourceHashes == nil {
+		return "establishes the baseline hashes, the first poll never triggers a job", false
+	}
+	// A job held outside the trigger windows triggers even without changes
+	pending := changeJob.Status.PendingTriggerReason
+	condition := describeCondition(&changeJob.Spec)
+	met := false
+	if len(changes) == 0 {
+		if pending == "" && incomplete {
+			return "no changes in the resources that could be compared", false
+		}
+		if pending == "" {
+			return "no changes, no job", false
+		}
+	} else if unchanged := unchangedRequired(&changeJob.Spec, changes); len(unchanged) > 0 {
+		if pending == "" {
+			return fmt.Sprintf("required resources %s did not change", strings.Join(unchanged, ", ")), false
+		}
+	} else if met = controller.ConditionMet(&changeJob.Spec, changes); !met && pending == "" {
+		return fmt.Sprintf("%d of %d resources changed, condition %s is not met", len(changes), len(changeJob.Spec.Resources), condition), false
+	}
+	if changeJob.Status.AutoSuspended {
+		return "suspended after a watched field flapped, the changes are recorded without triggering a job until acknowledged", false
+	}
+	if ptr.Deref(changeJob.Spec.Suspend, false) {
+		return "suspended, the changes are recorded without triggering a job", false
+	}
+	if open, next, err := controller.NextTriggerWindow(changeJob.Spec.TriggerWindows, o.now()); err != nil {
+		return fmt.Sprintf("invalid trigger windows: %v", err), false
+	} else if !open && next.IsZero() {
+		return "outside the trigger windows, the job is held until one opens", false
+	} else if !open {
+		return fmt.Sprintf("outside the trigger windows, the job is held until %s", next.UTC().Format(time.RFC3339)), false
+	}
+	if last := changeJob.Status.LastTriggeredTime; last != nil && changeJob.Spec.Cooldown != nil {
+		if until := last.Add(changeJob.Spec.Cooldown.Duration); o.now().Before(until) {
+			return fmt.Sprintf("in cooldown until %s, the changes are recorded without triggering a job", until.UTC().Format(time.RFC3339)), false
+		}
+	}
+	if limit := changeJob.Spec.RateLimit; limit != nil {
+		history := controller.PruneTriggerHistory(limit, changeJob.Status.TriggerHistory, o.now())
+		if allowed, next := controller.TriggerBudget(limit, history, o.now()); !allowed {
+			return fmt.Sprintf("rate limited, %d of %d jobs triggered in the last %s, the changes are %s until %s",
+				len(history), limit.MaxTriggers, limit.Window.Duration, controller.RateLimitAction(limit.Policy), next.UTC().Format(time.RFC3339)), false
+		}
+	}
+	if !met {
+		return fmt.Sprintf("triggers the pending job: %s", pending), true
+	}
+	if ptr.Deref(changeJob.Spec.Condition, "") == triggersv1alpha.TriggerConditionExpression {
+		return fmt.Sprintf("triggers a job: %s", controller.TriggerReason(&changeJob.Spec, changes)), true
+	}
+	return fmt.Sprintf("triggers a job, %d of %d resources changed and condition %s is met", len(changes), len(changeJob.Spec.Resources), condition), true
+}
+
+// describeCondition names the trigger condition, with its threshold for AtLeast
+func describeCondition(spec *triggersv1alpha.ChangeTriggeredJobSpec) string {
+	condition := ptr.Deref(spec.Condition, triggersv1alpha.TriggerConditionAny)
+	switch condition {
+	case triggersv1alpha.TriggerConditionAtLeast:
+		return fmt.Sprintf("%s %d", condition, ptr.Deref(spec.MinChanged, 1))
+	case triggersv1alpha.TriggerConditionExpression:
+		return fmt.Sprintf("%s %s", condition, spec.Expression)
+	}
+	return string(condition)
+}
+
+// unchangedRequired names the required resources that did not change
+func unchangedRequired(spec *triggersv1alpha.ChangeTriggeredJobSpec, changes []controller.ResourceChange) []string {
+	var unchanged []string
+	for _, ref := range spec.Resources {
+		if ref.Required && !slices.ContainsFunc(changes, func(change controller.ResourceChange) bool {
+			return change.Resource.APIVersion == ref.APIVersion && change.Resource.Kind == ref.Kind &&
+				change.Resource.Namespace == ref.Namespace && change.Resource.Name == ref.Name
+		}) {
+			unchanged = append(unchanged, resourceName(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name))
+		}
+	}
+	return unchanged
+}
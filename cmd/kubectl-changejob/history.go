@@ -0,0 +1,75 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/nusnewob/kube-changejob/internal/controller"
+)
+
+func newHistoryCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "history NAME",
+		Short: "List the jobs a ChangeTriggeredJob created and their outcomes, newest first",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, changeJob, err := o.get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			var jobs batchv1.JobList
+			if err := c.List(cmd.Context(), &jobs, client.InNamespace(changeJob.Namespace), client.MatchingLabels{controller.DefaultLabel: changeJob.Name}); err != nil {
+				return fmt.Errorf("unable to list jobs: %w", err)
+			}
+			owned := slices.DeleteFunc(jobs.Items, func(job batchv1.Job) bool {
+				return !slices.ContainsFunc(job.OwnerReferences, func(ref metav1.OwnerReference) bool { return ref.UID == changeJob.UID })
+			})
+			slices.SortFunc(owned, func(a, b batchv1.Job) int {
+				return b.CreationTimestamp.Compare(a.CreationTimestamp.Time)
+			})
+
+			w := tabwriter.NewWriter(o.out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSTATUS\tCREATED\tDURATION")
+			for _, job := range owned {
+				state := string(controller.JobStateOf(&job))
+				if state == "" {
+					state = "Pending"
+				}
+				elapsed := "<none>"
+				if job.Status.StartTime != nil {
+					end := o.now()
+					if job.Status.CompletionTime != nil {
+						end = job.Status.CompletionTime.Time
+					}
+					elapsed = duration.HumanDuration(end.Sub(job.Status.StartTime.Time))
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", job.Name, state, o.since(&job.CreationTimestamp), elapsed)
+			}
+			return w.Flush()
+		},
+	}
+}
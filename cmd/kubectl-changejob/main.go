@@ -0,0 +1,129 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-changejob operates ChangeTriggeredJobs. Installed on the PATH it runs as `kubectl changejob`.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(triggersv1alpha.AddToScheme(scheme))
+}
+
+func main() {
+	o := newOptions(os.Stdout)
+	if err := newRootCommand(o).Execute(); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(o.exitCode)
+}
+
+// options are shared by all subcommands
+type options struct {
+	loadingRules *clientcmd.ClientConfigLoadingRules
+	overrides    clientcmd.ConfigOverrides
+	out          io.Writer
+	// now returns the current time, replaced in tests
+	now func() time.Time
+	// newClient returns a client and the namespace to use, replaced in tests
+	newClient func() (client.Client, string, error)
+	// exitCode is the exit status after a successful command
+	exitCode int
+}
+
+func newOptions(out io.Writer) *options {
+	o := &options{
+		loadingRules: clientcmd.NewDefaultClientConfigLoadingRules(),
+		out:          out,
+		now:          time.Now,
+	}
+	o.newClient = o.kubeClient
+	return o
+}
+
+func newRootCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "kubectl-changejob",
+		Short:        "Operate ChangeTriggeredJobs",
+		SilenceUsage: true,
+	}
+	cmd.SetOut(o.out)
+	cmd.PersistentFlags().StringVar(&o.loadingRules.ExplicitPath, clientcmd.RecommendedConfigPathFlag, "", "Path to the kubeconfig file")
+	clientcmd.BindOverrideFlags(&o.overrides, cmd.PersistentFlags(), clientcmd.RecommendedConfigOverrideFlags(""))
+
+	cmd.AddCommand(
+		newStatusCommand(o),
+		newTriggerCommand(o),
+		newSuspendCommand(o, true),
+		newSuspendCommand(o, false),
+		newAcknowledgeCommand(o),
+		newHistoryCommand(o),
+		newExplainCommand(o),
+		newSimulateCommand(o),
+	)
+	return cmd
+}
+
+// kubeClient builds a client from the kubeconfig and flags
+func (o *options) kubeClient() (client.Client, string, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(o.loadingRules, &o.overrides)
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", err
+	}
+	return c, namespace, nil
+}
+
+// get returns the named ChangeTriggeredJob with the client it was read with
+func (o *options) get(ctx context.Context, name string) (client.Client, *triggersv1alpha.ChangeTriggeredJob, error) {
+	c, namespace, err := o.newClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	var changeJob triggersv1alpha.ChangeTriggeredJob
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &changeJob); err != nil {
+		return nil, nil, fmt.Errorf("unable to get ChangeTriggeredJob %s/%s: %w", namespace, name, err)
+	}
+	return c, &changeJob, nil
+}
@@ -0,0 +1,550 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/controller"
+)
+
+const (
+	testNamespace = "default"
+	testName      = "app-sync"
+)
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestClient returns a client with a ChangeTriggeredJob watching two ConfigMaps, its hashes taken before app-config changed
+func newTestClient(t *testing.T, mutate func(*triggersv1alpha.ChangeTriggeredJob)) client.Client {
+	t.Helper()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	appConfig := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: testNamespace}, Data: map[string]string{"key": "old"}}
+	flags := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "flags", Namespace: testNamespace}, Data: map[string]string{"beta": "on"}}
+	changeJob := &triggersv1alpha.ChangeTriggeredJob{
+		ObjectMeta: metav1.ObjectMeta{Name: testName, Namespace: testNamespace, UID: "ctj-uid"},
+		Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+			Resources: []triggersv1alpha.ResourceReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "app-config", Namespace: testNamespace, Fields: []string{"data.key"}},
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "flags", Namespace: testNamespace, Fields: []string{"data"}},
+			},
+			Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+			Cooldown:  &metav1.Duration{Duration: time.Minute},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).
+		WithObjects(appConfig, flags, changeJob).WithStatusSubresource(changeJob).Build()
+
+	ctx := context.Background()
+	poller := controller.Poller{Client: c}
+	for _, ref := range changeJob.Spec.Resources {
+		status, err := poller.Poll(ctx, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changeJob.Status.ResourceHashes = append(changeJob.Status.ResourceHashes, status)
+	}
+	changeJob.Status.LastJobName = testName + "-abcde"
+	changeJob.Status.LastJobStatus = triggersv1alpha.JobStateSucceeded
+	changeJob.Status.LastTriggerReason = "Condition Any met by v1/ConfigMap/default/app-config"
+	changeJob.Status.LastTriggeredTime = &metav1.Time{Time: testNow.Add(-time.Hour)}
+	meta.SetStatusCondition(&changeJob.Status.Conditions, metav1.Condition{
+		Type: triggersv1alpha.ConditionTypeDegraded, Status: metav1.ConditionFalse, Reason: triggersv1alpha.ReasonReconciled, Message: "Watching 2 resources",
+		LastTransitionTime: metav1.Time{Time: testNow.Add(-2 * time.Hour)},
+	})
+	if mutate != nil {
+		mutate(changeJob)
+	}
+	status := changeJob.Status.DeepCopy()
+	if err := c.Update(ctx, changeJob); err != nil {
+		t.Fatal(err)
+	}
+	changeJob.Status = *status
+	if err := c.Status().Update(ctx, changeJob); err != nil {
+		t.Fatal(err)
+	}
+
+	appConfig.Data["key"] = "new"
+	if err := c.Update(ctx, appConfig); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func run(t *testing.T, c client.Client, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	o := newOptions(&out)
+	o.now = func() time.Time { return testNow }
+	o.newClient = func() (client.Client, string, error) { return c, testNamespace, nil }
+	cmd := newRootCommand(o)
+	cmd.SetArgs(args)
+	cmd.SetErr(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Expected no error, got %v\n%s", err, out.String())
+	}
+	return out.String()
+}
+
+func getChangeJob(t *testing.T, c client.Client) *triggersv1alpha.ChangeTriggeredJob {
+	t.Helper()
+	var changeJob triggersv1alpha.ChangeTriggeredJob
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: testNamespace, Name: testName}, &changeJob); err != nil {
+		t.Fatal(err)
+	}
+	return &changeJob
+}
+
+func TestStatus(t *testing.T) {
+	c := newTestClient(t, func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+		changeJob.Spec.TriggerWindows = []triggersv1alpha.TriggerWindow{{Days: []triggersv1alpha.Weekday{"Sat", "Sun"}, Start: "22:00", End: "02:00", TimeZone: "Europe/Berlin"}}
+		changeJob.Status.PendingTriggerReason = "Condition Any met by v1/ConfigMap/default/flags"
+		changeJob.Status.NextEligibleTime = &metav1.Time{Time: testNow.Add(8 * time.Hour)}
+		changeJob.Spec.Schedule = "0 3 * * *"
+		changeJob.Status.NextScheduleTime = &metav1.Time{Time: testNow.Add(15 * time.Hour)}
+		changeJob.Status.LastTriggeredBy = triggersv1alpha.TriggeredByChanged
+		changeJob.Spec.RateLimit = &triggersv1alpha.TriggerRateLimit{MaxTriggers: 5, Window: metav1.Duration{Duration: time.Hour}, Policy: triggersv1alpha.RateLimitPolicyQueue}
+		changeJob.Status.TriggerHistory = []metav1.Time{{Time: testNow.Add(-2 * time.Hour)}, {Time: testNow.Add(-30 * time.Minute)}}
+		changeJob.Spec.FlappingDetection = &triggersv1alpha.FlappingDetection{Threshold: 5, Window: metav1.Duration{Duration: 10 * time.Minute}, AutoSuspend: true}
+		changeJob.Status.AutoSuspended = true
+	})
+	out := run(t, c, "status", testName)
+
+	changeJob := getChangeJob(t, c)
+	for _, expected := range []string{
+		"Condition:            Any",
+		"Last Job:             app-sync-abcde (Succeeded)",
+		"Last Trigger Reason:  Condition Any met by v1/ConfigMap/default/app-config",
+		"Trigger Windows:      Sat,Sun 22:00-02:00 Europe/Berlin",
+		"Pending Trigger:      Condition Any met by v1/ConfigMap/default/flags",
+		"Next Eligible:        2025-06-01T20:00:00Z (in 8h)",
+		"Schedule:             0 3 * * *",
+		"Next Scheduled Run:   2025-06-02T03:00:00Z (in 15h)",
+		"Last Triggered By:    Changed",
+		"Rate Limit:           5 per 1h0m0s (Queue), 1 triggered in the window",
+		"Suspended:            true (a watched field flapped, acknowledge to resume)",
+		"Flapping Detection:   5 changes per 10m0s, auto suspend true",
+		"(60m ago)",
+		"v1/ConfigMap default/app-config  data.key  " + shortHash(changeJob.Status.ResourceHashes[0].Fields[0].LastHash),
+		"Degraded   False   Reconciled  120m",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected output to contain %q, got\n%s", expected, out)
+		}
+	}
+}
+
+func TestTriggerSuspendResume(t *testing.T) {
+	c := newTestClient(t, nil)
+
+	if out := run(t, c, "trigger", testName); !strings.Contains(out, "trigger requested") {
+		t.Errorf("Expected a trigger request, got %q", out)
+	}
+	if requested := getChangeJob(t, c).Annotations[triggersv1alpha.TriggerRequestedAtAnnotation]; requested != testNow.Format(time.RFC3339Nano) {
+		t.Errorf("Expected the trigger request annotation to be the current time, got %q", requested)
+	}
+
+	if out := run(t, c, "acknowledge", testName); !strings.Contains(out, "flapping acknowledged") {
+		t.Errorf("Expected flapping to be acknowledged, got %q", out)
+	}
+	if acknowledged := getChangeJob(t, c).Annotations[triggersv1alpha.FlappingAcknowledgedAtAnnotation]; acknowledged != testNow.Format(time.RFC3339Nano) {
+		t.Errorf("Expected the acknowledgement annotation to be the current time, got %q", acknowledged)
+	}
+
+	run(t, c, "suspend", testName)
+	if !ptr.Deref(getChangeJob(t, c).Spec.Suspend, false) {
+		t.Error("Expected the ChangeTriggeredJob to be suspended")
+	}
+	run(t, c, "resume", testName)
+	if getChangeJob(t, c).Spec.Suspend != nil {
+		t.Error("Expected the ChangeTriggeredJob to be resumed")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	c := newTestClient(t, nil)
+	owner := []metav1.OwnerReference{{APIVersion: triggersv1alpha.GroupVersion.String(), Kind: "ChangeTriggeredJob", Name: testName, UID: "ctj-uid"}}
+	labels := map[string]string{controller.DefaultLabel: testName}
+	jobs := []*batchv1.Job{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-sync-old", Namespace: testNamespace, Labels: labels, OwnerReferences: owner, CreationTimestamp: metav1.Time{Time: testNow.Add(-2 * time.Hour)}},
+			Status: batchv1.JobStatus{
+				Failed:         1,
+				StartTime:      &metav1.Time{Time: testNow.Add(-2 * time.Hour)},
+				CompletionTime: &metav1.Time{Time: testNow.Add(-2*time.Hour + 30*time.Second)},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-sync-new", Namespace: testNamespace, Labels: labels, OwnerReferences: owner, CreationTimestamp: metav1.Time{Time: testNow.Add(-time.Hour)}},
+			Status:     batchv1.JobStatus{Succeeded: 1, StartTime: &metav1.Time{Time: testNow.Add(-time.Hour)}, CompletionTime: &metav1.Time{Time: testNow.Add(-time.Hour + time.Minute)}},
+		},
+		{
+			// Same label, owned by another ChangeTriggeredJob
+			ObjectMeta: metav1.ObjectMeta{Name: "app-sync-other", Namespace: testNamespace, Labels: labels, CreationTimestamp: metav1.Time{Time: testNow}},
+		},
+	}
+	for _, job := range jobs {
+		if err := c.Create(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := run(t, c, "history", testName)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 jobs, got\n%s", out)
+	}
+	if !strings.HasPrefix(lines[1], "app-sync-new") || !strings.Contains(lines[1], "Succeeded") || !strings.Contains(lines[1], "60s") {
+		t.Errorf("Expected the newest job first, succeeded after 60s, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "app-sync-old") || !strings.Contains(lines[2], "Failed") {
+		t.Errorf("Expected the failed job last, got %q", lines[2])
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(*triggersv1alpha.ChangeTriggeredJob)
+		expected []string
+	}{
+		{
+			name:     "changed",
+			expected: []string{"Last poll: Watching 2 resources", "default/app-config: data.key changed", "default/flags: no changes", "Next poll: triggers a job, 1 of 2 resources changed and condition Any is met"},
+		},
+		{
+			name: "condition not met",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+			},
+			expected: []string{"Next poll: 1 of 2 resources changed, condition All is not met"},
+		},
+		{
+			name: "accumulated",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+				changeJob.Status.ResourceHashes[1].ChangedAt = &metav1.Time{Time: testNow.Add(-5 * time.Minute)}
+			},
+			expected: []string{"default/flags: no changes, changed 5m ago since the last job", "Next poll: triggers a job, 2 of 2 resources changed and condition All is met"},
+		},
+		{
+			name: "accumulated change expired",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+				changeJob.Spec.ChangeWindow = &metav1.Duration{Duration: time.Minute}
+				changeJob.Status.ResourceHashes[1].ChangedAt = &metav1.Time{Time: testNow.Add(-5 * time.Minute)}
+			},
+			expected: []string{"default/flags: no changes\n", "Next poll: 1 of 2 resources changed, condition All is not met"},
+		},
+		{
+			name: "expression",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Resources[0].ID = "appConfig"
+				changeJob.Spec.Resources[1].ID = "flags"
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionExpression)
+				changeJob.Spec.Expression = "(appConfig && flags) || appConfig && !flags"
+			},
+			expected: []string{"condition Expression (appConfig && flags) || appConfig && !flags", `Next poll: triggers a job: Expression clause "appConfig && !flags" fired`},
+		},
+		{
+			name: "field condition not met",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				hash, err := controller.HashObject(map[string]any{"metadata.name": []any{"app-config"}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				changeJob.Spec.Resources[0].Fields = append(changeJob.Spec.Resources[0].Fields, "metadata.name")
+				changeJob.Spec.Resources[0].FieldCondition = ptr.To(triggersv1alpha.FieldConditionAll)
+				changeJob.Status.ResourceHashes[0].Fields = append(changeJob.Status.ResourceHashes[0].Fields, triggersv1alpha.ResourceFieldHash{Field: "metadata.name", LastHash: hash})
+			},
+			expected: []string{"default/app-config: only data.key changed, field condition All is not met", "Next poll: no changes, no job"},
+		},
+		{
+			name: "outside trigger windows",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.TriggerWindows = []triggersv1alpha.TriggerWindow{{Days: []triggersv1alpha.Weekday{"Sat"}, Start: "22:00", End: "23:00"}}
+			},
+			expected: []string{"Next poll: outside the trigger windows, the job is held until 2025-06-07T22:00:00Z"},
+		},
+		{
+			name: "pending",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+				changeJob.Status.PendingTriggerReason = "Condition All met by v1/ConfigMap/default/app-config, v1/ConfigMap/default/flags"
+			},
+			expected: []string{"Next poll: triggers the pending job: Condition All met by v1/ConfigMap/default/app-config, v1/ConfigMap/default/flags"},
+		},
+		{
+			name: "scheduled",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+				changeJob.Spec.Schedule = "0 * * * *"
+				changeJob.Status.LastScheduleTime = &metav1.Time{Time: testNow.Add(-90 * time.Minute)}
+			},
+			expected: []string{"Next poll: triggers the scheduled run at 2025-06-01T12:00:00Z, 1 of 2 resources changed, condition All is not met"},
+		},
+		{
+			name: "scheduled run missed the deadline",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+				changeJob.Spec.Schedule = "30 * * * *"
+				changeJob.Spec.StartingDeadlineSeconds = ptr.To[int64](60)
+				changeJob.Status.LastScheduleTime = &metav1.Time{Time: testNow.Add(-90 * time.Minute)}
+			},
+			expected: []string{"Next poll: 1 of 2 resources changed, condition All is not met"},
+		},
+		{
+			name: "rate limited",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.RateLimit = &triggersv1alpha.TriggerRateLimit{MaxTriggers: 2, Window: metav1.Duration{Duration: time.Hour}}
+				changeJob.Status.TriggerHistory = []metav1.Time{{Time: testNow.Add(-50 * time.Minute)}, {Time: testNow.Add(-10 * time.Minute)}}
+			},
+			expected: []string{"Next poll: rate limited, 2 of 2 jobs triggered in the last 1h0m0s, the changes are dropped until 2025-06-01T12:10:00Z"},
+		},
+		{
+			name:     "required unchanged",
+			mutate:   func(changeJob *triggersv1alpha.ChangeTriggeredJob) { changeJob.Spec.Resources[1].Required = true },
+			expected: []string{"Next poll: required resources v1/ConfigMap default/flags did not change"},
+		},
+		{
+			name: "at least",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAtLeast)
+				changeJob.Spec.MinChanged = ptr.To[int32](2)
+				changeJob.Spec.Resources[0].Weight = ptr.To[int32](2)
+			},
+			expected: []string{"condition AtLeast 2", "Next poll: triggers a job, 1 of 2 resources changed and condition AtLeast 2 is met"},
+		},
+		{
+			name: "suspended after flapping",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.FlappingDetection = &triggersv1alpha.FlappingDetection{Threshold: 3, Window: metav1.Duration{Duration: 10 * time.Minute}, AutoSuspend: true}
+				changeJob.Status.AutoSuspended = true
+			},
+			expected: []string{"Next poll: suspended after a watched field flapped"},
+		},
+		{
+			name:     "suspended",
+			mutate:   func(changeJob *triggersv1alpha.ChangeTriggeredJob) { changeJob.Spec.Suspend = ptr.To(true) },
+			expected: []string{"Next poll: suspended"},
+		},
+		{
+			name: "cooldown",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Spec.Cooldown = &metav1.Duration{Duration: 2 * time.Hour}
+			},
+			expected: []string{"Next poll: in cooldown until 2025-06-01T13:00:00Z"},
+		},
+		{
+			name: "keyed hashes",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Status.ResourceHashes[0].KeyID = "c58e442dec94496a"
+			},
+			expected: []string{"hashed with key c58e442dec94496a", "Next poll: no changes in the resources that could be compared"},
+		},
+		{
+			name: "requested",
+			mutate: func(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+				changeJob.Annotations = map[string]string{triggersv1alpha.TriggerRequestedAtAnnotation: "2025-06-01T11:59:00Z"}
+			},
+			expected: []string{"Next poll: triggers a job, requested at 2025-06-01T11:59:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := run(t, newTestClient(t, tt.mutate), "explain", testName)
+			for _, expected := range tt.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected output to contain %q, got\n%s", expected, out)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	changeJob := func(condition string) string {
+		spec := "condition: " + condition
+		if condition == "" {
+			spec = "pollInterval: 1m"
+		}
+		return write("changejob-"+condition+".yaml", `
+apiVersion: triggers.changejob.dev/v1alpha
+kind: ChangeTriggeredJob
+metadata:
+  name: app-sync
+spec:
+  `+spec+`
+  resources:
+    - apiVersion: v1
+      kind: ConfigMap
+      name: app-config
+      namespace: default
+      fields: ["data.key"]
+    - apiVersion: example.com/v1
+      kind: Widget
+      name: main
+      fields: ["spec.size"]
+      contextFields:
+        - field: spec.color
+          env: WIDGET_COLOR
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+            - name: sync
+              image: busybox
+`)
+	}
+	before := write("before.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  key: old
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: main
+spec:
+  size: 1
+`)
+	after := filepath.Dir(write("after/configmap.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\ndata:\n  key: new\n"))
+	write("after/widget.json", `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "main"}, "spec": {"size": 1, "color": "red"}}`)
+	missing := write("missing.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\n")
+	defaulted := write("changejob-defaulted.yaml", `
+apiVersion: triggers.changejob.dev/v1alpha
+kind: ChangeTriggeredJob
+metadata:
+  name: app-sync
+spec:
+  resources:
+    - apiVersion: v1
+      kind: ConfigMap
+      name: app-config
+      namespace: default
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+            - name: sync
+              image: busybox
+`)
+	allByDefault := write("config.yaml", "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\ndefaults:\n  condition: All\n")
+	defaultedBefore := write("defaulted-before.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\ndata:\n  a: \"1\"\n")
+	defaultedAfter := write("defaulted-after.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app-config\ndata:\n  a: \"2\"\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		exitCode int
+		err      string
+	}{
+		{
+			name: "triggered",
+			args: []string{"-f", changeJob("Any"), "--before", before, "--after", after, "--exit-code"},
+			expected: []string{
+				"ChangeTriggeredJob default/app-sync watches 2 resources, condition Any",
+				"v1/ConfigMap default/app-config: data.key changed",
+				"example.com/v1/Widget main: no changes",
+				"Result: triggers a job, 1 of 2 resources changed and condition Any is met",
+				"kind: Job", "generateName: app-sync-", "namespace: default", "changejob.dev/owner: app-sync", "image: busybox",
+				"name: WIDGET_COLOR", "value: red",
+				"changejob.dev/triggered-by: Changed", "changejob.dev/trigger-reason: Condition Any met by v1/ConfigMap/default/app-config",
+			},
+		},
+		{
+			name:     "condition not met",
+			args:     []string{"-f", changeJob("All"), "--before", before, "--after", after, "--exit-code"},
+			expected: []string{"Result: 1 of 2 resources changed, condition All is not met"},
+			exitCode: 2,
+		},
+		{
+			name: "schema defaults",
+			args: []string{"-f", defaulted, "--before", defaultedBefore, "--after", defaultedAfter, "--exit-code"},
+			expected: []string{
+				"ChangeTriggeredJob default/app-sync watches 1 resources, condition Any",
+				"Result: triggers a job, 1 of 1 resources changed and condition Any is met",
+			},
+		},
+		{
+			name:     "controller default condition",
+			args:     []string{"-f", changeJob(""), "--before", before, "--after", after, "--exit-code"},
+			expected: []string{"Result: triggers a job, 1 of 2 resources changed and condition Any is met"},
+		},
+		{
+			name:     "configured default condition",
+			args:     []string{"-f", changeJob(""), "--before", before, "--after", after, "--config", allByDefault, "--exit-code"},
+			expected: []string{"Result: 1 of 2 resources changed, condition All is not met"},
+			exitCode: 2,
+		},
+		{
+			name: "missing resource",
+			args: []string{"-f", changeJob("Any"), "--before", missing, "--after", after},
+			err:  `before: unable to poll example.com/v1/Widget main`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			o := newOptions(&out)
+			cmd := newRootCommand(o)
+			cmd.SetArgs(append([]string{"simulate"}, tt.args...))
+			cmd.SetErr(&out)
+			err := cmd.Execute()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error to contain %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v\n%s", err, out.String())
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("Expected output to contain %q, got\n%s", expected, out.String())
+				}
+			}
+			if o.exitCode != tt.exitCode {
+				t.Errorf("Expected exit code %d, got %d", tt.exitCode, o.exitCode)
+			}
+		})
+	}
+}
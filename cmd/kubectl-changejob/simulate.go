@@ -0,0 +1,356 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/config"
+	"github.com/nusnewob/kube-changejob/internal/controller"
+	webhookv1alpha "github.com/nusnewob/kube-changejob/internal/webhook/v1alpha"
+)
+
+func newSimulateCommand(o *options) *cobra.Command {
+	var file, configFile string
+	var before, after []string
+	var exitCode bool
+	cmd := &cobra.Command{
+		Use:   "simulate -f FILE --before FILE --after FILE",
+		Short: "Simulate a poll of local manifests and show whether it would trigger a job",
+		Long: `Simulate polls the watched resources in the --before manifests as the baseline, then the ones in the
+--after manifests, with the same field extraction, hashing and condition as the controller. It prints the
+changed fields, whether the poll would trigger a job and the rendered Job, without a cluster.
+
+Unset condition, cooldown and history default as the webhook defaults them, to the controller's defaults or
+the ones in the --config controller configuration file.
+
+Watched resources must be in both the --before and --after manifests. Resources of a kind watched with a
+namespace are namespaced, and default to the ChangeTriggeredJob's namespace. Files may hold several
+documents and Lists, "-" reads standard input.`,
+		Example: `  # Would changing the ConfigMap trigger a job?
+  kubectl changejob simulate -f changejob.yaml --before configmap.yaml --after configmap-new.yaml
+
+  # Fail a CI step unless a job would be created
+  kubectl changejob simulate -f changejob.yaml --before old/ --after new/ --exit-code`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.DefaultControllerConfig
+			if configFile != "" {
+				var err error
+				if cfg, err = config.LoadFile(configFile, cfg); err != nil {
+					return err
+				}
+			}
+			changeJob, err := readChangeTriggeredJob(cmd.InOrStdin(), file, o.overrides.Context.Namespace, cfg)
+			if err != nil {
+				return err
+			}
+			beforeObjects, err := readManifests(cmd.InOrStdin(), before)
+			if err != nil {
+				return err
+			}
+			afterObjects, err := readManifests(cmd.InOrStdin(), after)
+			if err != nil {
+				return err
+			}
+			triggered, err := o.simulate(cmd.Context(), changeJob, beforeObjects, afterObjects)
+			if err != nil {
+				return err
+			}
+			if exitCode && !triggered {
+				o.exitCode = 2
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "filename", "f", "", "File holding the ChangeTriggeredJob")
+	cmd.Flags().StringArrayVar(&before, "before", nil, "Files or directories holding the watched resources before the change")
+	cmd.Flags().StringArrayVar(&after, "after", nil, "Files or directories holding the watched resources after the change")
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false, "Exit with status 2 when no job would be triggered")
+	cmd.Flags().StringVar(&configFile, "config", "", "Controller configuration file whose defaults apply to unset fields")
+	_ = cmd.MarkFlagRequired("filename")
+	_ = cmd.MarkFlagRequired("before")
+	_ = cmd.MarkFlagRequired("after")
+	return cmd
+}
+
+// simulate polls the manifests before and after the change and reports whether a job would be triggered
+func (o *options) simulate(ctx context.Context, changeJob *triggersv1alpha.ChangeTriggeredJob, before, after []*unstructured.Unstructured) (bool, error) {
+	condition := describeCondition(&changeJob.Spec)
+	fmt.Fprintf(o.out, "ChangeTriggeredJob %s/%s watches %d resources, condition %s\n\n", changeJob.Namespace, changeJob.Name, len(changeJob.Spec.Resources), condition)
+
+	mapper := simulationMapper(changeJob, before, after)
+	last, err := pollManifests(ctx, mapper, changeJob, before)
+	if err != nil {
+		return false, fmt.Errorf("before: %w", err)
+	}
+	afterPoller, err := manifestPoller(mapper, changeJob, after)
+	if err != nil {
+		return false, fmt.Errorf("after: %w", err)
+	}
+	current, err := pollAll(ctx, afterPoller, changeJob.Spec.Resources)
+	if err != nil {
+		return false, fmt.Errorf("after: %w", err)
+	}
+
+	changeJob.Status.ResourceHashes = last
+	changes := controller.DetectChanges(changeJob.Spec.Resources, last, current)
+	fmt.Fprintln(o.out, "Changes:")
+	o.printChanges(last, current, changes)
+	fmt.Fprintln(o.out)
+
+	verdict, triggered := o.verdict(changeJob, changes, false)
+	fmt.Fprintf(o.out, "Result: %s\n", verdict)
+	if !triggered {
+		return false, nil
+	}
+
+	job, err := controller.NewJob(changeJob, scheme)
+	if err != nil {
+		return false, err
+	}
+	env, err := afterPoller.ContextEnv(ctx, changeJob.Spec.Resources)
+	if err != nil {
+		return false, fmt.Errorf("after: %w", err)
+	}
+	controller.SetEnv(job, env)
+	controller.AnnotateTrigger(job, triggersv1alpha.TriggeredByChanged, controller.TriggerReason(&changeJob.Spec, changes))
+	job.SetGroupVersionKind(batchv1.SchemeGroupVersion.WithKind("Job"))
+	manifest, err := yaml.Marshal(job)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(o.out, "\n---\n%s", manifest)
+	return true, nil
+}
+
+// pollManifests polls the watched resources from an in-memory client holding the manifests
+func pollManifests(ctx context.Context, mapper meta.RESTMapper, changeJob *triggersv1alpha.ChangeTriggeredJob, manifests []*unstructured.Unstructured) ([]triggersv1alpha.ResourceReferenceStatus, error) {
+	poller, err := manifestPoller(mapper, changeJob, manifests)
+	if err != nil {
+		return nil, err
+	}
+	return pollAll(ctx, poller, changeJob.Spec.Resources)
+}
+
+// manifestPoller returns a Poller reading from an in-memory client holding the manifests
+func manifestPoller(mapper meta.RESTMapper, changeJob *triggersv1alpha.ChangeTriggeredJob, manifests []*unstructured.Unstructured) (*controller.Poller, error) {
+	objects := make([]client.Object, 0, len(manifests))
+	seen := map[string]bool{}
+	for _, manifest := range manifests {
+		obj := manifest.DeepCopy()
+		mapping, err := mapper.RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+		if err != nil {
+			return nil, err
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			obj.SetNamespace("")
+		} else if obj.GetNamespace() == "" {
+			obj.SetNamespace(changeJob.Namespace)
+		}
+		name := resourceName(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		if seen[name] {
+			return nil, fmt.Errorf("%s is defined more than once", name)
+		}
+		seen[name] = true
+		obj.SetResourceVersion("")
+		objects = append(objects, obj)
+	}
+
+	return &controller.Poller{Client: fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(objects...).Build()}, nil
+}
+
+// pollAll polls every watched resource
+func pollAll(ctx context.Context, poller *controller.Poller, resources []triggersv1alpha.ResourceReference) ([]triggersv1alpha.ResourceReferenceStatus, error) {
+	statuses := make([]triggersv1alpha.ResourceReferenceStatus, 0, len(resources))
+	for _, ref := range resources {
+		status, err := poller.Poll(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to poll %s: %w", resourceName(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name), err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// simulationMapper maps the watched kinds and the kinds in the manifests. Without a cluster to discover scopes
+// from, kinds watched with a namespace, or else found with one in the manifests, are namespaced.
+func simulationMapper(changeJob *triggersv1alpha.ChangeTriggeredJob, manifests ...[]*unstructured.Unstructured) meta.RESTMapper {
+	namespaced := map[schema.GroupVersionKind]bool{}
+	for _, ref := range changeJob.Spec.Resources {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			continue
+		}
+		gvk := gv.WithKind(ref.Kind)
+		namespaced[gvk] = namespaced[gvk] || ref.Namespace != ""
+	}
+	for _, objects := range manifests {
+		for _, obj := range objects {
+			if _, ok := namespaced[obj.GroupVersionKind()]; !ok {
+				namespaced[obj.GroupVersionKind()] = obj.GetNamespace() != ""
+			}
+		}
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for gvk, ok := range namespaced {
+		if ok {
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+		} else {
+			mapper.Add(gvk, meta.RESTScopeRoot)
+		}
+	}
+	return mapper
+}
+
+// readChangeTriggeredJob reads the only ChangeTriggeredJob in the file, in namespace or default when it has none,
+// with the defaults the webhook with the controller configuration and the CRD schema would apply
+func readChangeTriggeredJob(stdin io.Reader, path string, namespace string, cfg config.ControllerConfig) (*triggersv1alpha.ChangeTriggeredJob, error) {
+	objects, err := readManifests(stdin, []string{path})
+	if err != nil {
+		return nil, err
+	}
+	gvk := triggersv1alpha.GroupVersion.WithKind("ChangeTriggeredJob")
+	var found []*unstructured.Unstructured
+	for _, obj := range objects {
+		if obj.GroupVersionKind() == gvk {
+			found = append(found, obj)
+		}
+	}
+	if len(found) != 1 {
+		return nil, fmt.Errorf("%s must hold exactly one %s, found %d", path, gvk, len(found))
+	}
+
+	changeJob := &triggersv1alpha.ChangeTriggeredJob{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(found[0].Object, changeJob, true); err != nil {
+		return nil, fmt.Errorf("invalid ChangeTriggeredJob in %s: %w", path, err)
+	}
+	defaulter := &webhookv1alpha.ChangeTriggeredJobCustomDefaulter{Config: config.NewStore(cfg)}
+	defaulter.DefaultSpec(&changeJob.Spec)
+	triggersv1alpha.SetSchemaDefaults(changeJob)
+	if changeJob.Namespace == "" {
+		changeJob.Namespace = namespace
+	}
+	if changeJob.Namespace == "" {
+		changeJob.Namespace = "default"
+	}
+	return changeJob, nil
+}
+
+// readManifests reads the objects in the files, the YAML and JSON files in directories and "-" for stdin, expanding Lists
+func readManifests(stdin io.Reader, paths []string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, path := range paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			r := stdin
+			if file != "-" {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return nil, err
+				}
+				r = bytes.NewReader(data)
+			}
+			decoded, err := decodeManifests(r)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %s: %w", file, err)
+			}
+			objects = append(objects, decoded...)
+		}
+	}
+	return objects, nil
+}
+
+// manifestFiles lists the YAML and JSON files in a directory, or the path itself otherwise
+func manifestFiles(path string) ([]string, error) {
+	if path == "-" {
+		return []string{path}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// decodeManifests decodes every non-empty YAML or JSON document, expanding Lists
+func decodeManifests(r io.Reader) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	var objects []*unstructured.Unstructured
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); errors.Is(err, io.EOF) {
+			return objects, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" || (!obj.IsList() && obj.GetName() == "") {
+			return nil, fmt.Errorf("objects must have apiVersion, kind and metadata.name")
+		}
+		if !obj.IsList() {
+			objects = append(objects, obj)
+			continue
+		}
+		if err := obj.EachListItem(func(item runtime.Object) error {
+			objects = append(objects, item.(*unstructured.Unstructured))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+}
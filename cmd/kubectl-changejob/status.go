@@ -0,0 +1,219 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/utils/ptr"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/controller"
+)
+
+// Length hashes are shortened to in tables
+const shortHashLength = 12
+
+func newStatusCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status NAME",
+		Short: "Show the watched resources, last hashes, last job and conditions of a ChangeTriggeredJob",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, changeJob, err := o.get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return o.printStatus(changeJob)
+		},
+	}
+}
+
+func (o *options) printStatus(changeJob *triggersv1alpha.ChangeTriggeredJob) error {
+	w := tabwriter.NewWriter(o.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", changeJob.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", changeJob.Namespace)
+	fmt.Fprintf(w, "Condition:\t%s\n", describeCondition(&changeJob.Spec))
+	if changeJob.Spec.Cooldown != nil {
+		fmt.Fprintf(w, "Cooldown:\t%s\n", changeJob.Spec.Cooldown.Duration)
+	}
+	if controller.AccumulatesChanges(&changeJob.Spec) {
+		window := "<none>"
+		if changeJob.Spec.ChangeWindow != nil {
+			window = changeJob.Spec.ChangeWindow.Duration.String()
+		}
+		fmt.Fprintf(w, "Change Window:\t%s\n", window)
+		var pending []string
+		for _, status := range changeJob.Status.ResourceHashes {
+			if status.ChangedAt != nil {
+				pending = append(pending, fmt.Sprintf("%s (%s ago)", resourceName(status.APIVersion, status.Kind, status.Namespace, status.Name), o.age(status.ChangedAt.Time)))
+			}
+		}
+		if len(pending) == 0 {
+			pending = []string{"<none>"}
+		}
+		fmt.Fprintf(w, "Changed Since Last Job:\t%s\n", strings.Join(pending, ", "))
+	}
+	if changeJob.Status.AutoSuspended {
+		fmt.Fprintf(w, "Suspended:\ttrue (a watched field flapped, acknowledge to resume)\n")
+	} else {
+		fmt.Fprintf(w, "Suspended:\t%t\n", ptr.Deref(changeJob.Spec.Suspend, false))
+	}
+	if detection := changeJob.Spec.FlappingDetection; detection != nil {
+		fmt.Fprintf(w, "Flapping Detection:\t%d changes per %s, auto suspend %t\n", detection.Threshold, detection.Window.Duration, detection.AutoSuspend)
+	}
+	if len(changeJob.Spec.TriggerWindows) > 0 {
+		windows := make([]string, 0, len(changeJob.Spec.TriggerWindows))
+		for _, window := range changeJob.Spec.TriggerWindows {
+			windows = append(windows, describeWindow(window))
+		}
+		fmt.Fprintf(w, "Trigger Windows:\t%s\n", strings.Join(windows, ", "))
+	}
+	if limit := changeJob.Spec.RateLimit; limit != nil {
+		policy := limit.Policy
+		if policy == "" {
+			policy = triggersv1alpha.RateLimitPolicyDrop
+		}
+		history := controller.PruneTriggerHistory(limit, changeJob.Status.TriggerHistory, o.now())
+		fmt.Fprintf(w, "Rate Limit:\t%d per %s (%s), %d triggered in the window\n", limit.MaxTriggers, limit.Window.Duration, policy, len(history))
+	}
+	if changeJob.Spec.Schedule != "" {
+		fmt.Fprintf(w, "Schedule:\t%s\n", changeJob.Spec.Schedule)
+		fmt.Fprintf(w, "Next Scheduled Run:\t%s\n", o.until(changeJob.Status.NextScheduleTime))
+	}
+	if changeJob.Status.PendingTriggerReason != "" {
+		fmt.Fprintf(w, "Pending Trigger:\t%s\n", changeJob.Status.PendingTriggerReason)
+		fmt.Fprintf(w, "Next Eligible:\t%s\n", o.until(changeJob.Status.NextEligibleTime))
+	}
+	fmt.Fprintf(w, "Last Triggered:\t%s\n", o.since(changeJob.Status.LastTriggeredTime))
+	fmt.Fprintf(w, "Last Job:\t%s\n", lastJob(changeJob))
+	if changeJob.Status.LastTriggeredBy != "" {
+		fmt.Fprintf(w, "Last Triggered By:\t%s\n", changeJob.Status.LastTriggeredBy)
+	}
+	if changeJob.Status.LastTriggerReason != "" {
+		fmt.Fprintf(w, "Last Trigger Reason:\t%s\n", changeJob.Status.LastTriggerReason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(o.out)
+	w = tabwriter.NewWriter(o.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tFIELD\tHASH\tKEY ID")
+	hashes := make(map[string]triggersv1alpha.ResourceReferenceStatus, len(changeJob.Status.ResourceHashes))
+	for _, status := range changeJob.Status.ResourceHashes {
+		hashes[resourceName(status.APIVersion, status.Kind, status.Namespace, status.Name)] = status
+	}
+	for _, ref := range changeJob.Spec.Resources {
+		name := resourceName(ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+		status, ok := hashes[name]
+		if !ok || len(status.Fields) == 0 {
+			fmt.Fprintf(w, "%s\t<none>\t<none>\t\n", name)
+			continue
+		}
+		for _, field := range status.Fields {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, field.Field, shortHash(field.LastHash), status.KeyID)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(o.out)
+	return o.printConditions(changeJob.Status.Conditions)
+}
+
+func (o *options) printConditions(conditions []metav1.Condition) error {
+	w := tabwriter.NewWriter(o.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONDITION\tSTATUS\tREASON\tAGE\tMESSAGE")
+	for _, condition := range conditions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason,
+			o.age(condition.LastTransitionTime.Time), condition.Message)
+	}
+	return w.Flush()
+}
+
+// since formats a time with its age, e.g., 2025-01-01T10:00:00Z (5m ago)
+func (o *options) since(t *metav1.Time) string {
+	if t == nil {
+		return "<never>"
+	}
+	return fmt.Sprintf("%s (%s ago)", t.UTC().Format(time.RFC3339), o.age(t.Time))
+}
+
+// until formats a time with the duration until it, e.g., 2025-01-01T10:00:00Z (in 5m)
+func (o *options) until(t *metav1.Time) string {
+	if t == nil {
+		return "<unknown>"
+	}
+	return fmt.Sprintf("%s (in %s)", t.UTC().Format(time.RFC3339), duration.HumanDuration(t.Sub(o.now())))
+}
+
+func (o *options) age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(o.now().Sub(t))
+}
+
+// describeWindow formats a trigger window, e.g., Mon,Tue 22:00-02:00 Europe/Berlin
+func describeWindow(window triggersv1alpha.TriggerWindow) string {
+	days := "daily"
+	if len(window.Days) > 0 {
+		names := make([]string, 0, len(window.Days))
+		for _, day := range window.Days {
+			names = append(names, string(day))
+		}
+		days = strings.Join(names, ",")
+	}
+	timeZone := window.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	return fmt.Sprintf("%s %s-%s %s", days, window.Start, window.End, timeZone)
+}
+
+func lastJob(changeJob *triggersv1alpha.ChangeTriggeredJob) string {
+	if changeJob.Status.LastJobName == "" {
+		return "<none>"
+	}
+	if changeJob.Status.LastJobStatus == "" {
+		return changeJob.Status.LastJobName
+	}
+	return fmt.Sprintf("%s (%s)", changeJob.Status.LastJobName, changeJob.Status.LastJobStatus)
+}
+
+// resourceName formats a watched resource, e.g., v1/ConfigMap default/app-config
+func resourceName(apiVersion, kind, namespace, name string) string {
+	if namespace == "" {
+		return fmt.Sprintf("%s/%s %s", apiVersion, kind, name)
+	}
+	return fmt.Sprintf("%s/%s %s/%s", apiVersion, kind, namespace, name)
+}
+
+func shortHash(hash string) string {
+	if len(hash) > shortHashLength {
+		return hash[:shortHashLength]
+	}
+	return hash
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+func newTriggerCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "trigger NAME",
+		Short: "Run a job now, regardless of changes, cooldown and suspension",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.annotateNow(cmd.Context(), args[0], triggersv1alpha.TriggerRequestedAtAnnotation); err != nil {
+				return fmt.Errorf("unable to request a trigger: %w", err)
+			}
+			fmt.Fprintf(o.out, "changetriggeredjob/%s trigger requested\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newAcknowledgeCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "acknowledge NAME",
+		Short: "Acknowledge flapping fields, resuming triggering if flapping suspended it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.annotateNow(cmd.Context(), args[0], triggersv1alpha.FlappingAcknowledgedAtAnnotation); err != nil {
+				return fmt.Errorf("unable to acknowledge flapping: %w", err)
+			}
+			fmt.Fprintf(o.out, "changetriggeredjob/%s flapping acknowledged\n", args[0])
+			return nil
+		},
+	}
+}
+
+// annotateNow sets an annotation of a ChangeTriggeredJob to the current time, a new value the controller acts on once
+func (o *options) annotateNow(ctx context.Context, name, annotation string) error {
+	c, changeJob, err := o.get(ctx, name)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(changeJob.DeepCopy())
+	if changeJob.Annotations == nil {
+		changeJob.Annotations = map[string]string{}
+	}
+	changeJob.Annotations[annotation] = o.now().UTC().Format(time.RFC3339Nano)
+	return c.Patch(ctx, changeJob, patch)
+}
+
+// newSuspendCommand returns the suspend command, or the resume command when suspend is false
+func newSuspendCommand(o *options, suspend bool) *cobra.Command {
+	use, short, done := "suspend NAME", "Stop triggering jobs on changes, changes are still recorded", "suspended"
+	if !suspend {
+		use, short, done = "resume NAME", "Resume triggering jobs on changes made after resuming", "resumed"
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, changeJob, err := o.get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			patch := client.MergeFrom(changeJob.DeepCopy())
+			changeJob.Spec.Suspend = nil
+			if suspend {
+				changeJob.Spec.Suspend = ptr.To(true)
+			}
+			if err := c.Patch(cmd.Context(), changeJob, patch); err != nil {
+				return fmt.Errorf("unable to update suspend: %w", err)
+			}
+			fmt.Fprintf(o.out, "changetriggeredjob/%s %s\n", changeJob.Name, done)
+			return nil
+		},
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -30,9 +32,12 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	kbzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -41,8 +46,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/cloudevents"
 	"github.com/nusnewob/kube-changejob/internal/config"
 	"github.com/nusnewob/kube-changejob/internal/controller"
+	"github.com/nusnewob/kube-changejob/internal/sharding"
 	webhookv1alpha "github.com/nusnewob/kube-changejob/internal/webhook/v1alpha"
 	// +kubebuilder:scaffold:imports
 )
@@ -162,18 +169,146 @@ func main() {
 
 	// Environment variable override
 	if v := os.Getenv("POLL_INTERVAL"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.PollInterval = d
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid POLL_INTERVAL %q: %v\n", v, err)
+			os.Exit(1)
 		}
+		cfg.PollInterval = d
 	}
 
-	// Command-line flag (highest priority)
+	watchNamespaces := os.Getenv("WATCH_NAMESPACES")
+
+	// Command-line flag, overridden by the fields set in the config file
+	var configFile string
+	flag.StringVar(&configFile, "config", "",
+		"Path to a ControllerConfiguration file, reloaded on change")
 	flag.DurationVar(&cfg.PollInterval, "poll-interval", cfg.PollInterval,
 		"Polling interval for ChangeTriggeredJob controller")
+	flag.DurationVar(&cfg.MinPollInterval, "min-poll-interval", cfg.MinPollInterval,
+		"Minimum spec.pollInterval, shorter intervals are clamped")
+	flag.DurationVar(&cfg.MaxPollInterval, "max-poll-interval", cfg.MaxPollInterval,
+		"Maximum spec.pollInterval, longer intervals are clamped")
+	flag.Float64Var(&cfg.PollJitter, "poll-jitter", cfg.PollJitter,
+		"Maximum random fraction added to each poll interval (0 to 1)")
+	flag.IntVar(&cfg.MaxConcurrentReconciles, "max-concurrent-reconciles", cfg.MaxConcurrentReconciles,
+		"Maximum number of ChangeTriggeredJobs reconciled in parallel")
+	var pollQPSPerKind float64
+	flag.Float64Var(&pollQPSPerKind, "poll-qps-per-kind", float64(cfg.PollQPSPerKind),
+		"Polls per second allowed for every watched resource kind, 0 is unlimited")
+	flag.IntVar(&cfg.PollBurstPerKind, "poll-burst-per-kind", cfg.PollBurstPerKind,
+		"Poll burst allowed for every watched resource kind")
+	cfg.ShardIdentity = os.Getenv("POD_NAME")
+	if cfg.ShardIdentity == "" {
+		cfg.ShardIdentity, _ = os.Hostname()
+	}
+	cfg.ShardNamespace = os.Getenv("POD_NAMESPACE")
+	flag.IntVar(&cfg.Shards, "shards", cfg.Shards,
+		"Number of shards ChangeTriggeredJobs are split into between replicas, 0 disables sharding")
+	flag.StringVar(&cfg.ShardIdentity, "shard-identity", cfg.ShardIdentity,
+		"Unique name of this replica in the shard group, defaults to POD_NAME or the hostname")
+	flag.StringVar(&cfg.ShardNamespace, "shard-namespace", cfg.ShardNamespace,
+		"Namespace of the shard Leases, defaults to POD_NAMESPACE")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", watchNamespaces,
+		"Comma-separated namespaces to watch ChangeTriggeredJobs and Jobs in, empty watches all namespaces")
+	var cachedKinds string
+	flag.StringVar(&cachedKinds, "cached-kinds", "",
+		"Comma-separated kinds of watched resources read from an informer cache, as apiVersion/Kind, e.g. v1/ConfigMap")
+	flag.BoolVar(&cfg.EnforcePolicies, "enforce-policies", cfg.EnforcePolicies,
+		"Enforce ChangeJobPolicies, requires cluster-wide read access to ChangeJobPolicies and Namespaces")
+
+	flag.StringVar(&cfg.EventSinkURL, "event-sink-url", cfg.EventSinkURL,
+		"Default CloudEvents sink URL for detected changes and job outcomes, empty disables events")
+	flag.StringVar(&cfg.EventSinkMode, "event-sink-mode", cfg.EventSinkMode,
+		"CloudEvents content mode for the default sink (binary or structured)")
+	eventSinkAllowedHosts := strings.Join(cfg.EventSinkAllowedHosts, ",")
+	flag.StringVar(&eventSinkAllowedHosts, "event-sink-allowed-hosts", eventSinkAllowedHosts,
+		"Comma-separated hosts ChangeTriggeredJobs may send events to with spec.eventSink, exactly or as *.domain, empty allows only the default sink")
+
+	flag.StringVar(&cfg.HashKeySecret, "hash-key-secret", cfg.HashKeySecret,
+		"Secret holding the HMAC key for field hashes as namespace/name, empty stores plain SHA256 hashes")
+	flag.StringVar(&cfg.HashKeyScope, "hash-key-scope", cfg.HashKeyScope,
+		"Resources hashed with the HMAC key (Secrets or All)")
 
 	// opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	if cfg.EventSinkMode != cloudevents.ModeBinary && cfg.EventSinkMode != cloudevents.ModeStructured {
+		fmt.Fprintf(os.Stderr, "invalid event sink mode %q, must be binary or structured\n", cfg.EventSinkMode)
+		os.Exit(1)
+	}
+
+	if cfg.MinPollInterval > cfg.MaxPollInterval {
+		fmt.Fprintf(os.Stderr, "invalid poll interval bounds, min %s is greater than max %s\n", cfg.MinPollInterval, cfg.MaxPollInterval)
+		os.Exit(1)
+	}
+	if cfg.MaxConcurrentReconciles < 1 {
+		fmt.Fprintf(os.Stderr, "invalid max concurrent reconciles %d, must be >= 1\n", cfg.MaxConcurrentReconciles)
+		os.Exit(1)
+	}
+	if pollQPSPerKind < 0 || cfg.PollBurstPerKind < 1 {
+		fmt.Fprintf(os.Stderr, "invalid per-kind poll rate limit %v/%d, qps must be >= 0 and burst >= 1\n", pollQPSPerKind, cfg.PollBurstPerKind)
+		os.Exit(1)
+	}
+	cfg.PollQPSPerKind = float32(pollQPSPerKind)
+
+	if cfg.PollJitter < 0 || cfg.PollJitter > 1 {
+		fmt.Fprintf(os.Stderr, "invalid poll jitter %v, must be between 0 and 1\n", cfg.PollJitter)
+		os.Exit(1)
+	}
+
+	cfg.WatchNamespaces = parseList(watchNamespaces)
+	cfg.CachedKinds = parseList(cachedKinds)
+	cfg.EventSinkAllowedHosts = parseList(eventSinkAllowedHosts)
+
+	if cfg.HashKeyScope != config.HashKeyScopeSecrets && cfg.HashKeyScope != config.HashKeyScopeAll {
+		fmt.Fprintf(os.Stderr, "invalid hash key scope %q, must be Secrets or All\n", cfg.HashKeyScope)
+		os.Exit(1)
+	}
+
+	// Config file (highest priority)
+	store := config.NewStore(cfg)
+	if configFile != "" {
+		var err error
+		if store, err = config.LoadStore(configFile, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		cfg = store.Get()
+	}
+
+	for _, kind := range cfg.CachedKinds {
+		if _, err := config.ParseKind(kind); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid cached kind: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if cfg.Shards < 0 {
+		fmt.Fprintf(os.Stderr, "invalid shards %d, must be >= 0\n", cfg.Shards)
+		os.Exit(1)
+	}
+	if cfg.Shards > 0 {
+		if msgs := validation.IsDNS1123Subdomain(cfg.ShardIdentity); len(msgs) > 0 {
+			fmt.Fprintf(os.Stderr, "invalid shard identity %q: %s\n", cfg.ShardIdentity, strings.Join(msgs, ", "))
+			os.Exit(1)
+		}
+		if cfg.ShardNamespace == "" {
+			fmt.Fprintln(os.Stderr, "sharding requires --shard-namespace or POD_NAMESPACE")
+			os.Exit(1)
+		}
+	}
+
+	var hashKeySecret types.NamespacedName
+	if cfg.HashKeySecret != "" {
+		namespace, name, ok := strings.Cut(cfg.HashKeySecret, "/")
+		if !ok || namespace == "" || name == "" {
+			fmt.Fprintf(os.Stderr, "invalid hash key secret %q, must be namespace/name\n", cfg.HashKeySecret)
+			os.Exit(1)
+		}
+		hashKeySecret = types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
 	ctrl.SetLogger(kbzap.New(kbzap.UseFlagOptions(&opts)))
 
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
@@ -243,8 +378,23 @@ func main() {
 		metricsServerOptions.KeyName = metricsCertKey
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// Restrict the cache, and so the watches on ChangeTriggeredJobs and Jobs, to the watched namespaces
+	var cacheOptions cache.Options
+	if len(cfg.WatchNamespaces) > 0 {
+		setupLog.Info("Watching namespaces", "namespaces", cfg.WatchNamespaces)
+		cacheOptions.DefaultNamespaces = make(map[string]cache.Config, len(cfg.WatchNamespaces))
+		for _, ns := range cfg.WatchNamespaces {
+			cacheOptions.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = cfg.QPS
+	restConfig.Burst = cfg.Burst
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cacheOptions,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -267,21 +417,66 @@ func main() {
 		os.Exit(1)
 	}
 
+	if configFile != "" {
+		if err := mgr.Add(store); err != nil {
+			setupLog.Error(err, "Failed to watch config file")
+			os.Exit(1)
+		}
+	}
+
+	var sharder *sharding.Sharder
+	if cfg.Shards > 0 {
+		setupLog.Info("Sharding ChangeTriggeredJobs", "shards", cfg.Shards, "identity", cfg.ShardIdentity, "namespace", cfg.ShardNamespace)
+		sharder = sharding.NewSharder(mgr.GetClient(), mgr.GetAPIReader(), cfg.ShardNamespace, "kube-changejob", cfg.ShardIdentity, cfg.Shards)
+		if err := mgr.Add(sharder); err != nil {
+			setupLog.Error(err, "Failed to add sharder")
+			os.Exit(1)
+		}
+	}
+
+	// Deliver CloudEvents in the background so a slow sink never blocks a reconcile
+	events := cloudevents.NewDispatcher(cloudevents.DefaultQueueSize)
+	if err := mgr.Add(events); err != nil {
+		setupLog.Error(err, "Failed to add event dispatcher")
+		os.Exit(1)
+	}
+
+	var hashKeys *controller.HashKeyProvider
+	if cfg.HashKeySecret != "" {
+		hashKeys = &controller.HashKeyProvider{
+			Reader: mgr.GetAPIReader(),
+			Secret: hashKeySecret,
+			TTL:    controller.DefaultHashKeyTTL,
+		}
+	}
+
 	if err := (&controller.ChangeTriggeredJobReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Config: cfg,
-		Log:    ctrl.Log.WithName("controllers").WithName("ChangeTriggeredJob"),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Config:          cfg,
+		ConfigStore:     store,
+		Log:             ctrl.Log.WithName("controllers").WithName("ChangeTriggeredJob"),
+		RestConfig:      mgr.GetConfig(),
+		HashKeys:        hashKeys,
+		PollRateLimiter: controller.NewGVKRateLimiter(cfg.PollQPSPerKind, cfg.PollBurstPerKind),
+		Sharder:         sharder,
+		Cache:           mgr.GetCache(),
+		Recorder:        mgr.GetEventRecorder("changetriggeredjob-controller"),
+		Events:          events,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "Failed to create controller", "controller", "changetriggeredjob")
 		os.Exit(1)
 	}
 	// nolint:goconst
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
-		if err := webhookv1alpha.SetupChangeTriggeredJobWebhookWithManager(mgr); err != nil {
+		if err := webhookv1alpha.SetupChangeTriggeredJobWebhookWithManager(mgr, store); err != nil {
 			setupLog.Error(err, "Failed to create webhook", "webhook", "ChangeTriggeredJob")
 			os.Exit(1)
 		}
+		if err := webhookv1alpha.SetupChangeJobPolicyWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "Failed to create webhook", "webhook", "ChangeJobPolicy")
+			os.Exit(1)
+		}
 	}
 	// +kubebuilder:scaffold:builder
 
@@ -300,3 +495,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// parseList splits a comma-separated list such as namespaces or kinds, ignoring blanks and duplicates
+func parseList(value string) []string {
+	var namespaces []string
+	for ns := range strings.SplitSeq(value, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" && !slices.Contains(namespaces, ns) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
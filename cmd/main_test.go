@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"slices"
 	"testing"
 	"time"
 
@@ -802,3 +803,40 @@ func TestCertificatePathValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: nil,
+		},
+		{
+			name:     "single namespace",
+			value:    "team-a",
+			expected: []string{"team-a"},
+		},
+		{
+			name:     "multiple namespaces with spaces",
+			value:    "team-a, team-b ,team-c",
+			expected: []string{"team-a", "team-b", "team-c"},
+		},
+		{
+			name:     "blanks and duplicates",
+			value:    "team-a,,team-a, ",
+			expected: []string{"team-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseList(tt.value); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -61,3 +61,16 @@ Otherwise, use the standard resourceName helper with "controller-manager" suffix
 {{- include "kube-changejob.resourceName" (dict "suffix" "controller-manager" "context" .) }}
 {{- end }}
 {{- end }}
+
+{{/*
+Namespaces watched by the manager, comma separated.
+Uses manager.watchNamespaces, or the release namespace when rbac.namespaced is set.
+Empty watches all namespaces.
+*/}}
+{{- define "kube-changejob.watchNamespaces" -}}
+{{- if .Values.manager.watchNamespaces }}
+{{- join "," .Values.manager.watchNamespaces }}
+{{- else if .Values.rbac.namespaced }}
+{{- .Release.Namespace }}
+{{- end }}
+{{- end }}
@@ -0,0 +1,182 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloudevents delivers controller events to a CloudEvents HTTP sink.
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CloudEvents specification version the events conform to.
+const SpecVersion = "1.0"
+
+// Event types emitted by the controller. The trailing segment is the version of
+// the event data schema and is bumped on incompatible changes.
+const (
+	TypeResourceChanged = "dev.changejob.resource.changed.v1"
+	TypeJobTriggered    = "dev.changejob.job.triggered.v1"
+	TypeJobSucceeded    = "dev.changejob.job.succeeded.v1"
+	TypeJobFailed       = "dev.changejob.job.failed.v1"
+)
+
+// Content modes of the HTTP protocol binding
+const (
+	ModeBinary     = "binary"
+	ModeStructured = "structured"
+)
+
+const (
+	contentTypeJSON       = "application/json"
+	contentTypeCloudEvent = "application/cloudevents+json"
+)
+
+// Event is a single CloudEvent, Data is encoded as JSON
+type Event struct {
+	ID      string
+	Source  string
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    any
+}
+
+// ResourceChangedData is the data of TypeResourceChanged events
+type ResourceChangedData struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Name       string   `json:"name"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Fields     []string `json:"fields"`
+}
+
+// JobData is the data of TypeJobTriggered, TypeJobSucceeded and TypeJobFailed events
+type JobData struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Sink receives events
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// HTTPSink posts events to an HTTP endpoint
+type HTTPSink struct {
+	URL    string
+	Mode   string
+	Client *http.Client
+}
+
+// defaultClient does not follow redirects, which could lead to hosts outside the allowed event sink hosts
+var defaultClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Send delivers the event, any non-2xx response is an error
+func (s *HTTPSink) Send(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("unable to encode event data: %w", err)
+	}
+
+	var body []byte
+	header := http.Header{}
+	switch s.Mode {
+	case ModeStructured:
+		envelope := map[string]any{
+			"specversion":     SpecVersion,
+			"id":              event.ID,
+			"source":          event.Source,
+			"type":            event.Type,
+			"time":            event.Time.UTC().Format(time.RFC3339Nano),
+			"datacontenttype": contentTypeJSON,
+			"data":            json.RawMessage(data),
+		}
+		if event.Subject != "" {
+			envelope["subject"] = event.Subject
+		}
+		if body, err = json.Marshal(envelope); err != nil {
+			return fmt.Errorf("unable to encode event: %w", err)
+		}
+		header.Set("Content-Type", contentTypeCloudEvent)
+	case ModeBinary, "":
+		body = data
+		header.Set("Content-Type", contentTypeJSON)
+		header.Set("ce-specversion", SpecVersion)
+		header.Set("ce-id", event.ID)
+		header.Set("ce-source", event.Source)
+		header.Set("ce-type", event.Type)
+		header.Set("ce-time", event.Time.UTC().Format(time.RFC3339Nano))
+		if event.Subject != "" {
+			header.Set("ce-subject", event.Subject)
+		}
+	default:
+		return fmt.Errorf("unsupported content mode %q", s.Mode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+
+	client := s.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sink %s responded with %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// AllowedHost reports whether the host of a sink URL matches one of the allowed hosts. An entry matches the host
+// exactly, or any of its subdomains when it starts with "*.".
+func AllowedHost(rawURL string, allowed []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, entry := range allowed {
+		entry = strings.ToLower(entry)
+		if domain, ok := strings.CutPrefix(entry, "*."); ok {
+			if strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		} else if host == entry {
+			return true
+		}
+	}
+	return false
+}
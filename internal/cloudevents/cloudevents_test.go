@@ -0,0 +1,183 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testEventID     = "1234"
+	testEventSource = "/apis/triggers.changejob.dev/v1alpha/namespaces/default/changetriggeredjobs/test"
+	testJobName     = "test-abcde"
+)
+
+func testEvent() Event {
+	return Event{
+		ID:      testEventID,
+		Source:  testEventSource,
+		Type:    TypeJobTriggered,
+		Subject: testJobName,
+		Time:    time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+		Data:    JobData{Name: testJobName, Namespace: "default"},
+	}
+}
+
+func TestHTTPSinkBinaryMode(t *testing.T) {
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := &HTTPSink{URL: server.URL, Mode: ModeBinary}
+	if err := sink.Send(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]string{
+		"Content-Type":   contentTypeJSON,
+		"Ce-Specversion": SpecVersion,
+		"Ce-Id":          testEventID,
+		"Ce-Source":      testEventSource,
+		"Ce-Type":        TypeJobTriggered,
+		"Ce-Subject":     testJobName,
+		"Ce-Time":        "2025-01-15T10:30:00Z",
+	}
+	for k, v := range expected {
+		if got := header.Get(k); got != v {
+			t.Errorf("Expected header %s to be %q, got %q", k, v, got)
+		}
+	}
+
+	var data JobData
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatalf("Expected body to be JSON event data, got %v", err)
+	}
+	if data.Name != testJobName {
+		t.Errorf("Expected data name to be %q, got %q", testJobName, data.Name)
+	}
+}
+
+func TestHTTPSinkStructuredMode(t *testing.T) {
+	var contentType string
+	var envelope map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&envelope)
+	}))
+	defer server.Close()
+
+	sink := &HTTPSink{URL: server.URL, Mode: ModeStructured}
+	if err := sink.Send(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if contentType != contentTypeCloudEvent {
+		t.Errorf("Expected content type %q, got %q", contentTypeCloudEvent, contentType)
+	}
+	for k, v := range map[string]string{
+		"specversion":     SpecVersion,
+		"id":              testEventID,
+		"source":          testEventSource,
+		"type":            TypeJobTriggered,
+		"subject":         testJobName,
+		"datacontenttype": contentTypeJSON,
+	} {
+		if envelope[k] != v {
+			t.Errorf("Expected attribute %s to be %q, got %v", k, v, envelope[k])
+		}
+	}
+	data, ok := envelope["data"].(map[string]any)
+	if !ok || data["name"] != testJobName {
+		t.Errorf("Expected data to contain job name, got %v", envelope["data"])
+	}
+}
+
+func TestHTTPSinkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		sink *HTTPSink
+	}{
+		{
+			name: "non-2xx response",
+			sink: &HTTPSink{URL: server.URL, Mode: ModeBinary},
+		},
+		{
+			name: "unsupported mode",
+			sink: &HTTPSink{URL: server.URL, Mode: "batched"},
+		},
+		{
+			name: "unreachable endpoint",
+			sink: &HTTPSink{URL: "http://127.0.0.1:0", Mode: ModeBinary},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sink.Send(context.Background(), testEvent()); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestHTTPSinkDoesNotFollowRedirects(t *testing.T) {
+	var redirected atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected.Store(true)
+	}))
+	defer target.Close()
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer sink.Close()
+
+	err := (&HTTPSink{URL: sink.URL, Mode: ModeBinary}).Send(context.Background(), testEvent())
+	if err == nil || !strings.Contains(err.Error(), "307") {
+		t.Errorf("Expected the redirect to fail the delivery, got %v", err)
+	}
+	if redirected.Load() {
+		t.Error("Expected the redirect not to be followed")
+	}
+}
+
+func TestAllowedHost(t *testing.T) {
+	allowed := []string{"broker.default.svc", "*.example.com"}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "http://broker.default.svc/events", want: true},
+		{url: "http://BROKER.default.svc:8080", want: true},
+		{url: "https://events.example.com/hook", want: true},
+		{url: "https://example.com/hook", want: false},
+		{url: "https://evilexample.com/hook", want: false},
+		{url: "http://kubernetes.default.svc", want: false},
+		{url: "http://169.254.169.254/latest/meta-data", want: false},
+		{url: "://", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := AllowedHost(tt.url, allowed); got != tt.want {
+			t.Errorf("AllowedHost(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+	if AllowedHost("http://broker.default.svc", nil) {
+		t.Error("Expected no host to be allowed by an empty list")
+	}
+}
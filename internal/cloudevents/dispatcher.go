@@ -0,0 +1,112 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultQueueSize is the number of events a Dispatcher holds before dropping new ones
+const DefaultQueueSize = 1024
+
+// Dispatcher delivers events from a bounded queue in the background, retrying failed deliveries with exponential
+// backoff, so a slow or unreachable sink never blocks the caller
+type Dispatcher struct {
+	// Number of concurrent deliveries
+	Workers int
+	// Delivery attempts per event before it is given up
+	Attempts int
+	// Delay before the first retry, doubled on every following retry up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	queue chan delivery
+}
+
+type delivery struct {
+	sink  Sink
+	event Event
+	done  func(error)
+}
+
+// NewDispatcher returns a Dispatcher queueing up to size events
+func NewDispatcher(size int) *Dispatcher {
+	return &Dispatcher{
+		Workers:    4,
+		Attempts:   5,
+		Backoff:    time.Second,
+		MaxBackoff: 30 * time.Second,
+		queue:      make(chan delivery, size),
+	}
+}
+
+// Enqueue queues an event for delivery without blocking, it returns false when the queue is full and the event is
+// dropped. done, if not nil, is called once with the final delivery error, nil when delivered.
+func (d *Dispatcher) Enqueue(sink Sink, event Event, done func(error)) bool {
+	select {
+	case d.queue <- delivery{sink: sink, event: event, done: done}:
+		return true
+	default:
+		return false
+	}
+}
+
+// NeedLeaderElection returns false, every replica delivers the events of the ChangeTriggeredJobs it reconciles
+func (d *Dispatcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Start delivers queued events until ctx is done
+func (d *Dispatcher) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for range max(d.Workers, 1) {
+		wg.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case item := <-d.queue:
+					err := d.deliver(ctx, item)
+					if item.done != nil {
+						item.done(err)
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+	return nil
+}
+
+// deliver sends an event, retrying until it is delivered, the attempts run out or ctx is done
+func (d *Dispatcher) deliver(ctx context.Context, item delivery) error {
+	backoff := d.Backoff
+	for attempt := 1; ; attempt++ {
+		err := item.sink.Send(ctx, item.event)
+		if err == nil || attempt >= d.Attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, d.MaxBackoff)
+	}
+}
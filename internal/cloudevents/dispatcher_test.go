@@ -0,0 +1,108 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSink fails the first failures sends, and blocks every send until release is closed when set
+type fakeSink struct {
+	mu       sync.Mutex
+	sends    int
+	failures int
+	release  chan struct{}
+}
+
+func (s *fakeSink) Send(ctx context.Context, event Event) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sends++
+	if s.sends <= s.failures {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func startDispatcher(t *testing.T, d *Dispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		_ = d.Start(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	d := NewDispatcher(1)
+	d.Backoff = time.Millisecond
+	startDispatcher(t, d)
+
+	sink := &fakeSink{failures: 2}
+	done := make(chan error, 1)
+	if !d.Enqueue(sink, testEvent(), func(err error) { done <- err }) {
+		t.Fatal("Expected the event to be queued")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Expected the event to be delivered, got %v", err)
+	}
+	if sink.sends != 3 {
+		t.Errorf("Expected 3 attempts, got %d", sink.sends)
+	}
+
+	exhausted := &fakeSink{failures: 10}
+	if !d.Enqueue(exhausted, testEvent(), func(err error) { done <- err }) {
+		t.Fatal("Expected the event to be queued")
+	}
+	if err := <-done; err == nil {
+		t.Error("Expected an error once the attempts run out")
+	}
+	if exhausted.sends != d.Attempts {
+		t.Errorf("Expected %d attempts, got %d", d.Attempts, exhausted.sends)
+	}
+}
+
+func TestDispatcherDropsWhenFull(t *testing.T) {
+	d := NewDispatcher(1)
+	d.Workers = 1
+	startDispatcher(t, d)
+
+	sink := &fakeSink{release: make(chan struct{})}
+	defer close(sink.release)
+	if !d.Enqueue(sink, testEvent(), nil) {
+		t.Fatal("Expected the first event to be queued")
+	}
+
+	// The worker blocks on the first event, so one more fills the queue and the next is dropped without blocking
+	deadline := time.Now().Add(time.Second)
+	for d.Enqueue(sink, testEvent(), nil) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the queue to fill up")
+		}
+	}
+}
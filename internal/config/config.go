@@ -1,8 +1,131 @@
 // internal/config/config.go
 package config
 
-import "time"
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
 
 type ControllerConfig struct {
 	PollInterval time.Duration
+	// Bounds for spec.pollInterval, zero is unbounded
+	MinPollInterval time.Duration
+	MaxPollInterval time.Duration
+	// Maximum random fraction added to each poll interval to spread polls over time
+	PollJitter float64
+
+	// Values the webhook sets unset ChangeTriggeredJob fields to
+	DefaultCooldown  time.Duration
+	DefaultCondition string
+	DefaultHistory   int32
+
+	// Maximum number of ChangeTriggeredJobs reconciled in parallel
+	MaxConcurrentReconciles int
+	// API server client rate limits
+	QPS   float32
+	Burst int
+	// Workqueue rate limits, the slower of a per-item exponential backoff between retries and an overall token bucket
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	QueueQPS       float32
+	QueueBurst     int
+	// Polls per second for every watched GroupVersionKind, zero is unlimited
+	PollQPSPerKind   float32
+	PollBurstPerKind int
+
+	// Feature gates overriding DefaultFeatureGates
+	FeatureGates map[string]bool
+
+	// Number of shards ChangeTriggeredJobs are split into between replicas, zero disables sharding
+	Shards int
+	// Unique name of this replica and namespace of the shard Leases
+	ShardIdentity  string
+	ShardNamespace string
+
+	// Namespaces ChangeTriggeredJobs and Jobs are watched in, empty watches all namespaces
+	WatchNamespaces []string
+	// Kinds of watched resources read from a shared informer cache instead of the API server, as apiVersion/Kind
+	CachedKinds []string
+
+	// Enforce ChangeJobPolicies in the webhook and the reconciler
+	EnforcePolicies bool
+
+	// Default CloudEvents sink, used when a ChangeTriggeredJob does not set its own
+	EventSinkURL  string
+	EventSinkMode string
+	// Hosts ChangeTriggeredJobs may send events to with spec.eventSink, exactly or as "*.domain";
+	// empty allows only the default sink
+	EventSinkAllowedHosts []string
+
+	// Secret holding the HMAC key for field hashes, as namespace/name; empty stores plain SHA256 hashes
+	HashKeySecret string
+	// Resources hashed with the HMAC key, Secrets or All
+	HashKeyScope string
+}
+
+// Values of ControllerConfig.HashKeyScope
+const (
+	HashKeyScopeSecrets = "Secrets"
+	HashKeyScopeAll     = "All"
+)
+
+// EffectivePollInterval returns the requested poll interval clamped to the bounds, or PollInterval when zero. The
+// bounds only apply to requested intervals, not to the controller's own PollInterval.
+func (c ControllerConfig) EffectivePollInterval(requested time.Duration) time.Duration {
+	if requested <= 0 {
+		return c.PollInterval
+	}
+	interval := requested
+	if c.MinPollInterval > 0 && interval < c.MinPollInterval {
+		interval = c.MinPollInterval
+	}
+	if c.MaxPollInterval > 0 && interval > c.MaxPollInterval {
+		interval = c.MaxPollInterval
+	}
+	return interval
+}
+
+// ParseKind parses a kind written as apiVersion/Kind, e.g. v1/ConfigMap or apps/v1/Deployment
+func ParseKind(kind string) (schema.GroupVersionKind, error) {
+	i := strings.LastIndex(kind, "/")
+	if i <= 0 || i == len(kind)-1 {
+		return schema.GroupVersionKind{}, fmt.Errorf("kind must be apiVersion/Kind, got %q", kind)
+	}
+	gv, err := schema.ParseGroupVersion(kind[:i])
+	if err != nil || gv.Version == "" {
+		return schema.GroupVersionKind{}, fmt.Errorf("kind must be apiVersion/Kind, got %q", kind)
+	}
+	return gv.WithKind(kind[i+1:]), nil
+}
+
+// Feature gates
+const (
+	// Emit CloudEvents for detected changes and job outcomes
+	FeatureCloudEvents = "CloudEvents"
+	// Warn at admission about fields that do not resolve against the live object
+	FeatureFieldWarnings = "FieldWarnings"
+)
+
+// DefaultFeatureGates lists every known feature gate and whether it is enabled by default
+var DefaultFeatureGates = map[string]bool{
+	FeatureCloudEvents:   true,
+	FeatureFieldWarnings: true,
+}
+
+// KnownFeatureGates returns the names of all feature gates
+func KnownFeatureGates() []string {
+	return slices.Sorted(maps.Keys(DefaultFeatureGates))
+}
+
+// Enabled reports whether a feature gate is enabled
+func (c ControllerConfig) Enabled(feature string) bool {
+	if enabled, ok := c.FeatureGates[feature]; ok {
+		return enabled
+	}
+	return DefaultFeatureGates[feature]
 }
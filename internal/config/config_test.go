@@ -9,6 +9,25 @@ func TestDefaultControllerConfig(t *testing.T) {
 	if DefaultControllerConfig.PollInterval != 60*time.Second {
 		t.Errorf("Expected DefaultControllerConfig.PollInterval to be 60s, got %v", DefaultControllerConfig.PollInterval)
 	}
+	if DefaultControllerConfig.DefaultCooldown != 60*time.Second || DefaultControllerConfig.DefaultCondition != "Any" || DefaultControllerConfig.DefaultHistory != 5 {
+		t.Errorf("Expected default cooldown, condition and history to be 60s, Any and 5, got %v, %q and %d",
+			DefaultControllerConfig.DefaultCooldown, DefaultControllerConfig.DefaultCondition, DefaultControllerConfig.DefaultHistory)
+	}
+	if DefaultControllerConfig.EventSinkURL != "" {
+		t.Errorf("Expected DefaultControllerConfig.EventSinkURL to be empty, got %q", DefaultControllerConfig.EventSinkURL)
+	}
+	if DefaultControllerConfig.EventSinkMode != "binary" {
+		t.Errorf("Expected DefaultControllerConfig.EventSinkMode to be binary, got %q", DefaultControllerConfig.EventSinkMode)
+	}
+	if !DefaultControllerConfig.EnforcePolicies {
+		t.Error("Expected DefaultControllerConfig.EnforcePolicies to be true")
+	}
+	if DefaultControllerConfig.HashKeySecret != "" {
+		t.Errorf("Expected DefaultControllerConfig.HashKeySecret to be empty, got %q", DefaultControllerConfig.HashKeySecret)
+	}
+	if DefaultControllerConfig.HashKeyScope != HashKeyScopeSecrets {
+		t.Errorf("Expected DefaultControllerConfig.HashKeyScope to be %s, got %q", HashKeyScopeSecrets, DefaultControllerConfig.HashKeyScope)
+	}
 }
 
 func TestControllerConfig(t *testing.T) {
@@ -41,3 +60,89 @@ func TestControllerConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestFeatureGates(t *testing.T) {
+	cfg := DefaultControllerConfig
+	for _, gate := range KnownFeatureGates() {
+		if cfg.Enabled(gate) != DefaultFeatureGates[gate] {
+			t.Errorf("Expected feature gate %s to default to %v", gate, DefaultFeatureGates[gate])
+		}
+	}
+
+	cfg.FeatureGates = map[string]bool{FeatureCloudEvents: false}
+	if cfg.Enabled(FeatureCloudEvents) {
+		t.Errorf("Expected feature gate %s to be disabled", FeatureCloudEvents)
+	}
+	if !cfg.Enabled(FeatureFieldWarnings) {
+		t.Errorf("Expected feature gate %s to keep its default", FeatureFieldWarnings)
+	}
+	if cfg.Enabled("Unknown") {
+		t.Error("Expected unknown feature gates to be disabled")
+	}
+}
+
+func TestEffectivePollInterval(t *testing.T) {
+	cfg := ControllerConfig{PollInterval: time.Minute, MinPollInterval: 10 * time.Second, MaxPollInterval: time.Hour}
+	tests := []struct {
+		name      string
+		requested time.Duration
+		expected  time.Duration
+	}{
+		{name: "unset uses the controller poll interval", requested: 0, expected: time.Minute},
+		{name: "within bounds", requested: 30 * time.Second, expected: 30 * time.Second},
+		{name: "below the minimum", requested: time.Second, expected: 10 * time.Second},
+		{name: "above the maximum", requested: 2 * time.Hour, expected: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.EffectivePollInterval(tt.requested); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if got := (ControllerConfig{PollInterval: time.Minute}).EffectivePollInterval(time.Second); got != time.Second {
+		t.Errorf("Expected zero bounds to be unbounded, got %v", got)
+	}
+
+	// The bounds only apply to spec.pollInterval, an existing controller poll interval is kept
+	fast := ControllerConfig{PollInterval: 5 * time.Second, MinPollInterval: 10 * time.Second, MaxPollInterval: time.Hour}
+	if got := fast.EffectivePollInterval(0); got != 5*time.Second {
+		t.Errorf("Expected the controller poll interval below the minimum to be kept, got %v", got)
+	}
+	slow := ControllerConfig{PollInterval: 2 * time.Hour, MinPollInterval: 10 * time.Second, MaxPollInterval: time.Hour}
+	if got := slow.EffectivePollInterval(0); got != 2*time.Hour {
+		t.Errorf("Expected the controller poll interval above the maximum to be kept, got %v", got)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	tests := []struct {
+		kind     string
+		expected string
+		valid    bool
+	}{
+		{kind: "v1/ConfigMap", expected: "/v1, Kind=ConfigMap", valid: true},
+		{kind: "apps/v1/Deployment", expected: "apps/v1, Kind=Deployment", valid: true},
+		{kind: "ConfigMap"},
+		{kind: "v1/"},
+		{kind: "/ConfigMap"},
+		{kind: "a/b/c/Kind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			gvk, err := ParseKind(tt.kind)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", gvk)
+				}
+				return
+			}
+			if err != nil || gvk.String() != tt.expected {
+				t.Errorf("Expected %s, got %v and %v", tt.expected, gvk, err)
+			}
+		})
+	}
+}
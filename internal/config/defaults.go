@@ -4,5 +4,23 @@ package config
 import "time"
 
 var DefaultControllerConfig = ControllerConfig{
-	PollInterval: 60 * time.Second,
+	PollInterval:            60 * time.Second,
+	MinPollInterval:         10 * time.Second,
+	MaxPollInterval:         time.Hour,
+	PollJitter:              0.1,
+	DefaultCooldown:         60 * time.Second,
+	DefaultCondition:        "Any",
+	DefaultHistory:          5,
+	MaxConcurrentReconciles: 1,
+	QPS:                     20,
+	Burst:                   30,
+	RetryBaseDelay:          5 * time.Millisecond,
+	RetryMaxDelay:           5 * time.Minute,
+	QueueQPS:                10,
+	QueueBurst:              100,
+	PollQPSPerKind:          5,
+	PollBurstPerKind:        10,
+	EnforcePolicies:         true,
+	EventSinkMode:           "binary",
+	HashKeyScope:            HashKeyScopeSecrets,
 }
@@ -0,0 +1,376 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Version and kind of the configuration file
+const (
+	FileAPIVersion = "config.changejob.dev/v1alpha"
+	FileKind       = "ControllerConfiguration"
+)
+
+// ControllerConfiguration is the configuration file format, unset fields keep the value from flags or defaults. Fields
+// set to an empty value, e.g., `url: ""` or `allowedHosts: []`, override them, so a reload can clear a value.
+type ControllerConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	PollInterval    *metav1.Duration `json:"pollInterval,omitempty"`
+	MinPollInterval *metav1.Duration `json:"minPollInterval,omitempty"`
+	MaxPollInterval *metav1.Duration `json:"maxPollInterval,omitempty"`
+	PollJitter      *float64         `json:"pollJitter,omitempty"`
+
+	Defaults     *DefaultsConfiguration    `json:"defaults,omitempty"`
+	Concurrency  *ConcurrencyConfiguration `json:"concurrency,omitempty"`
+	RateLimits   *RateLimitsConfiguration  `json:"rateLimits,omitempty"`
+	EventSink    *EventSinkConfiguration   `json:"eventSink,omitempty"`
+	FeatureGates map[string]bool           `json:"featureGates,omitempty"`
+	HashKey      *HashKeyConfiguration     `json:"hashKey,omitempty"`
+	Namespaces   *NamespacesConfiguration  `json:"namespaces,omitempty"`
+	Policies     *PoliciesConfiguration    `json:"policies,omitempty"`
+	Sharding     *ShardingConfiguration    `json:"sharding,omitempty"`
+	Cache        *CacheConfiguration       `json:"cache,omitempty"`
+}
+
+// DefaultsConfiguration sets the values the webhook defaults unset ChangeTriggeredJob fields to
+type DefaultsConfiguration struct {
+	Cooldown *metav1.Duration `json:"cooldown,omitempty"`
+	// Condition is Any or All, the conditions that need no other ChangeTriggeredJob fields
+	Condition *string `json:"condition,omitempty"`
+	History   *int32  `json:"history,omitempty"`
+}
+
+// ConcurrencyConfiguration limits parallel reconciles, applied at startup only
+type ConcurrencyConfiguration struct {
+	MaxConcurrentReconciles *int `json:"maxConcurrentReconciles,omitempty"`
+}
+
+// RateLimitsConfiguration limits requests to the API server, applied at startup only
+type RateLimitsConfiguration struct {
+	QPS         *float32                      `json:"qps,omitempty"`
+	Burst       *int                          `json:"burst,omitempty"`
+	Queue       *QueueRateLimitsConfiguration `json:"queue,omitempty"`
+	PollPerKind *PollRateLimitsConfiguration  `json:"pollPerKind,omitempty"`
+}
+
+// QueueRateLimitsConfiguration limits how fast ChangeTriggeredJobs are retried and requeued
+type QueueRateLimitsConfiguration struct {
+	BaseDelay *metav1.Duration `json:"baseDelay,omitempty"`
+	MaxDelay  *metav1.Duration `json:"maxDelay,omitempty"`
+	QPS       *float32         `json:"qps,omitempty"`
+	Burst     *int             `json:"burst,omitempty"`
+}
+
+// PollRateLimitsConfiguration limits polls of every watched GroupVersionKind
+type PollRateLimitsConfiguration struct {
+	QPS   *float32 `json:"qps,omitempty"`
+	Burst *int     `json:"burst,omitempty"`
+}
+
+// EventSinkConfiguration sets the default CloudEvents sink
+type EventSinkConfiguration struct {
+	URL          *string  `json:"url,omitempty"`
+	Mode         *string  `json:"mode,omitempty"`
+	AllowedHosts []string `json:"allowedHosts,omitempty"`
+}
+
+// HashKeyConfiguration sets the HMAC key for field hashes, the secret is applied at startup only
+type HashKeyConfiguration struct {
+	Secret *string `json:"secret,omitempty"`
+	Scope  *string `json:"scope,omitempty"`
+}
+
+// NamespacesConfiguration restricts the watched namespaces, applied at startup only
+type NamespacesConfiguration struct {
+	Watch []string `json:"watch,omitempty"`
+}
+
+// PoliciesConfiguration toggles ChangeJobPolicy enforcement, applied at startup only
+type PoliciesConfiguration struct {
+	Enforce *bool `json:"enforce,omitempty"`
+}
+
+// ShardingConfiguration splits ChangeTriggeredJobs between replicas, applied at startup only
+type ShardingConfiguration struct {
+	Shards *int `json:"shards,omitempty"`
+}
+
+// CacheConfiguration lists the kinds of watched resources read from a shared informer cache, applied at startup only
+type CacheConfiguration struct {
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+// LoadFile reads and validates a configuration file and applies it on top of base
+func LoadFile(path string, base ControllerConfig) (ControllerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, fmt.Errorf("unable to read config file: %w", err)
+	}
+
+	var file ControllerConfiguration
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return base, fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+	if err := file.Validate(); err != nil {
+		return base, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	cfg := file.Apply(base)
+	if cfg.MinPollInterval > 0 && cfg.MaxPollInterval > 0 && cfg.MinPollInterval > cfg.MaxPollInterval {
+		return base, fmt.Errorf("invalid config file %s: minPollInterval %s is greater than maxPollInterval %s", path, cfg.MinPollInterval, cfg.MaxPollInterval)
+	}
+	return cfg, nil
+}
+
+// Validate reports every invalid setting at once
+func (f *ControllerConfiguration) Validate() error {
+	var errs []error
+	if f.APIVersion != FileAPIVersion || f.Kind != FileKind {
+		errs = append(errs, fmt.Errorf("apiVersion and kind must be %s %s, got %q %q", FileAPIVersion, FileKind, f.APIVersion, f.Kind))
+	}
+	if f.PollInterval != nil && f.PollInterval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("pollInterval: must be > 0, got %s", f.PollInterval.Duration))
+	}
+	if f.MinPollInterval != nil && f.MinPollInterval.Duration < 0 {
+		errs = append(errs, fmt.Errorf("minPollInterval: must be >= 0, got %s", f.MinPollInterval.Duration))
+	}
+	if f.MaxPollInterval != nil && f.MaxPollInterval.Duration < 0 {
+		errs = append(errs, fmt.Errorf("maxPollInterval: must be >= 0, got %s", f.MaxPollInterval.Duration))
+	}
+	if f.PollJitter != nil && (*f.PollJitter < 0 || *f.PollJitter > 1) {
+		errs = append(errs, fmt.Errorf("pollJitter: must be between 0 and 1, got %v", *f.PollJitter))
+	}
+	if d := f.Defaults; d != nil {
+		if d.Cooldown != nil && d.Cooldown.Duration < 0 {
+			errs = append(errs, fmt.Errorf("defaults.cooldown: must be >= 0, got %s", d.Cooldown.Duration))
+		}
+		if d.Condition != nil && *d.Condition != "Any" && *d.Condition != "All" {
+			errs = append(errs, fmt.Errorf("defaults.condition: must be Any or All, AtLeast and Expression need fields set per ChangeTriggeredJob, got %q",
+				*d.Condition))
+		}
+		if d.History != nil && *d.History < 1 {
+			errs = append(errs, fmt.Errorf("defaults.history: must be >= 1, got %d", *d.History))
+		}
+	}
+	if c := f.Concurrency; c != nil && c.MaxConcurrentReconciles != nil && *c.MaxConcurrentReconciles < 1 {
+		errs = append(errs, fmt.Errorf("concurrency.maxConcurrentReconciles: must be >= 1, got %d", *c.MaxConcurrentReconciles))
+	}
+	if r := f.RateLimits; r != nil {
+		if r.QPS != nil && *r.QPS <= 0 {
+			errs = append(errs, fmt.Errorf("rateLimits.qps: must be > 0, got %v", *r.QPS))
+		}
+		if r.Burst != nil && *r.Burst < 1 {
+			errs = append(errs, fmt.Errorf("rateLimits.burst: must be >= 1, got %d", *r.Burst))
+		}
+		if q := r.Queue; q != nil {
+			if q.BaseDelay != nil && q.BaseDelay.Duration <= 0 {
+				errs = append(errs, fmt.Errorf("rateLimits.queue.baseDelay: must be > 0, got %s", q.BaseDelay.Duration))
+			}
+			if q.MaxDelay != nil && q.MaxDelay.Duration <= 0 {
+				errs = append(errs, fmt.Errorf("rateLimits.queue.maxDelay: must be > 0, got %s", q.MaxDelay.Duration))
+			}
+			if q.BaseDelay != nil && q.MaxDelay != nil && q.BaseDelay.Duration > q.MaxDelay.Duration {
+				errs = append(errs, fmt.Errorf("rateLimits.queue.baseDelay: must be <= maxDelay %s, got %s", q.MaxDelay.Duration, q.BaseDelay.Duration))
+			}
+			if q.QPS != nil && *q.QPS <= 0 {
+				errs = append(errs, fmt.Errorf("rateLimits.queue.qps: must be > 0, got %v", *q.QPS))
+			}
+			if q.Burst != nil && *q.Burst < 1 {
+				errs = append(errs, fmt.Errorf("rateLimits.queue.burst: must be >= 1, got %d", *q.Burst))
+			}
+		}
+		if p := r.PollPerKind; p != nil {
+			if p.QPS != nil && *p.QPS < 0 {
+				errs = append(errs, fmt.Errorf("rateLimits.pollPerKind.qps: must be >= 0, got %v", *p.QPS))
+			}
+			if p.Burst != nil && *p.Burst < 1 {
+				errs = append(errs, fmt.Errorf("rateLimits.pollPerKind.burst: must be >= 1, got %d", *p.Burst))
+			}
+		}
+	}
+	if e := f.EventSink; e != nil && e.Mode != nil && *e.Mode != "binary" && *e.Mode != "structured" {
+		errs = append(errs, fmt.Errorf("eventSink.mode: must be binary or structured, got %q", *e.Mode))
+	}
+	if h := f.HashKey; h != nil {
+		if h.Scope != nil && *h.Scope != HashKeyScopeSecrets && *h.Scope != HashKeyScopeAll {
+			errs = append(errs, fmt.Errorf("hashKey.scope: must be %s or %s, got %q", HashKeyScopeSecrets, HashKeyScopeAll, *h.Scope))
+		}
+		if h.Secret != nil && *h.Secret != "" {
+			if namespace, name, ok := strings.Cut(*h.Secret, "/"); !ok || namespace == "" || name == "" {
+				errs = append(errs, fmt.Errorf("hashKey.secret: must be namespace/name, got %q", *h.Secret))
+			}
+		}
+	}
+	if s := f.Sharding; s != nil && s.Shards != nil && *s.Shards < 0 {
+		errs = append(errs, fmt.Errorf("sharding.shards: must be >= 0, got %d", *s.Shards))
+	}
+	if c := f.Cache; c != nil {
+		for _, kind := range c.Kinds {
+			if _, err := ParseKind(kind); err != nil {
+				errs = append(errs, fmt.Errorf("cache.kinds: %w", err))
+			}
+		}
+	}
+	for _, gate := range slices.Sorted(maps.Keys(f.FeatureGates)) {
+		if _, ok := DefaultFeatureGates[gate]; !ok {
+			errs = append(errs, fmt.Errorf("featureGates: unknown feature gate %q, known gates are %v", gate, KnownFeatureGates()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Apply overrides the fields of base the file sets
+func (f *ControllerConfiguration) Apply(base ControllerConfig) ControllerConfig {
+	cfg := base
+	if f.PollInterval != nil {
+		cfg.PollInterval = f.PollInterval.Duration
+	}
+	if f.MinPollInterval != nil {
+		cfg.MinPollInterval = f.MinPollInterval.Duration
+	}
+	if f.MaxPollInterval != nil {
+		cfg.MaxPollInterval = f.MaxPollInterval.Duration
+	}
+	if f.PollJitter != nil {
+		cfg.PollJitter = *f.PollJitter
+	}
+	if d := f.Defaults; d != nil {
+		if d.Cooldown != nil {
+			cfg.DefaultCooldown = d.Cooldown.Duration
+		}
+		if d.Condition != nil {
+			cfg.DefaultCondition = *d.Condition
+		}
+		if d.History != nil {
+			cfg.DefaultHistory = *d.History
+		}
+	}
+	if c := f.Concurrency; c != nil && c.MaxConcurrentReconciles != nil {
+		cfg.MaxConcurrentReconciles = *c.MaxConcurrentReconciles
+	}
+	if r := f.RateLimits; r != nil {
+		if r.QPS != nil {
+			cfg.QPS = *r.QPS
+		}
+		if r.Burst != nil {
+			cfg.Burst = *r.Burst
+		}
+		if q := r.Queue; q != nil {
+			if q.BaseDelay != nil {
+				cfg.RetryBaseDelay = q.BaseDelay.Duration
+			}
+			if q.MaxDelay != nil {
+				cfg.RetryMaxDelay = q.MaxDelay.Duration
+			}
+			if q.QPS != nil {
+				cfg.QueueQPS = *q.QPS
+			}
+			if q.Burst != nil {
+				cfg.QueueBurst = *q.Burst
+			}
+		}
+		if p := r.PollPerKind; p != nil {
+			if p.QPS != nil {
+				cfg.PollQPSPerKind = *p.QPS
+			}
+			if p.Burst != nil {
+				cfg.PollBurstPerKind = *p.Burst
+			}
+		}
+	}
+	if e := f.EventSink; e != nil {
+		if e.URL != nil {
+			cfg.EventSinkURL = *e.URL
+		}
+		if e.Mode != nil {
+			cfg.EventSinkMode = *e.Mode
+		}
+		if e.AllowedHosts != nil {
+			cfg.EventSinkAllowedHosts = e.AllowedHosts
+		}
+	}
+	if len(f.FeatureGates) > 0 {
+		cfg.FeatureGates = make(map[string]bool, len(base.FeatureGates)+len(f.FeatureGates))
+		for gate, enabled := range base.FeatureGates {
+			cfg.FeatureGates[gate] = enabled
+		}
+		for gate, enabled := range f.FeatureGates {
+			cfg.FeatureGates[gate] = enabled
+		}
+	}
+	if h := f.HashKey; h != nil {
+		if h.Secret != nil {
+			cfg.HashKeySecret = *h.Secret
+		}
+		if h.Scope != nil {
+			cfg.HashKeyScope = *h.Scope
+		}
+	}
+	if n := f.Namespaces; n != nil && n.Watch != nil {
+		cfg.WatchNamespaces = n.Watch
+	}
+	if p := f.Policies; p != nil && p.Enforce != nil {
+		cfg.EnforcePolicies = *p.Enforce
+	}
+	if s := f.Sharding; s != nil && s.Shards != nil {
+		cfg.Shards = *s.Shards
+	}
+	if c := f.Cache; c != nil && c.Kinds != nil {
+		cfg.CachedKinds = c.Kinds
+	}
+	return cfg
+}
+
+// KeepStartupSettings returns loaded with the settings only applied at startup reset to their running values,
+// and the names of those settings that differ
+func KeepStartupSettings(running, loaded ControllerConfig) (ControllerConfig, []string) {
+	var changed []string
+	if running.MaxConcurrentReconciles != loaded.MaxConcurrentReconciles {
+		changed = append(changed, "concurrency.maxConcurrentReconciles")
+		loaded.MaxConcurrentReconciles = running.MaxConcurrentReconciles
+	}
+	if running.QPS != loaded.QPS || running.Burst != loaded.Burst {
+		changed = append(changed, "rateLimits")
+		loaded.QPS, loaded.Burst = running.QPS, running.Burst
+	}
+	if running.RetryBaseDelay != loaded.RetryBaseDelay || running.RetryMaxDelay != loaded.RetryMaxDelay ||
+		running.QueueQPS != loaded.QueueQPS || running.QueueBurst != loaded.QueueBurst {
+		changed = append(changed, "rateLimits.queue")
+		loaded.RetryBaseDelay, loaded.RetryMaxDelay = running.RetryBaseDelay, running.RetryMaxDelay
+		loaded.QueueQPS, loaded.QueueBurst = running.QueueQPS, running.QueueBurst
+	}
+	if running.PollQPSPerKind != loaded.PollQPSPerKind || running.PollBurstPerKind != loaded.PollBurstPerKind {
+		changed = append(changed, "rateLimits.pollPerKind")
+		loaded.PollQPSPerKind, loaded.PollBurstPerKind = running.PollQPSPerKind, running.PollBurstPerKind
+	}
+	if running.HashKeySecret != loaded.HashKeySecret {
+		changed = append(changed, "hashKey.secret")
+		loaded.HashKeySecret = running.HashKeySecret
+	}
+	if !slices.Equal(running.WatchNamespaces, loaded.WatchNamespaces) {
+		changed = append(changed, "namespaces.watch")
+		loaded.WatchNamespaces = running.WatchNamespaces
+	}
+	if running.EnforcePolicies != loaded.EnforcePolicies {
+		changed = append(changed, "policies.enforce")
+		loaded.EnforcePolicies = running.EnforcePolicies
+	}
+	if running.Shards != loaded.Shards {
+		changed = append(changed, "sharding.shards")
+		loaded.Shards = running.Shards
+	}
+	if !slices.Equal(running.CachedKinds, loaded.CachedKinds) {
+		changed = append(changed, "cache.kinds")
+		loaded.CachedKinds = running.CachedKinds
+	}
+	return loaded, changed
+}
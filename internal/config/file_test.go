@@ -0,0 +1,225 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), `
+apiVersion: config.changejob.dev/v1alpha
+kind: ControllerConfiguration
+pollInterval: 30s
+defaults:
+  cooldown: 5m
+  condition: All
+  history: 10
+concurrency:
+  maxConcurrentReconciles: 4
+rateLimits:
+  qps: 50
+  burst: 100
+  queue:
+    baseDelay: 10ms
+    maxDelay: 1m
+  pollPerKind:
+    qps: 2
+featureGates:
+  CloudEvents: false
+cache:
+  kinds: [v1/ConfigMap, apps/v1/Deployment]
+eventSink:
+  allowedHosts: ["*.knative.svc"]
+`)
+	base := DefaultControllerConfig
+	base.EventSinkURL = "http://sink"
+
+	cfg, err := LoadFile(path, base)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.PollInterval != 30*time.Second {
+		t.Errorf("Expected PollInterval to be 30s, got %v", cfg.PollInterval)
+	}
+	if cfg.DefaultCooldown != 5*time.Minute || cfg.DefaultCondition != "All" || cfg.DefaultHistory != 10 {
+		t.Errorf("Expected defaults 5m, All and 10, got %v, %q and %d", cfg.DefaultCooldown, cfg.DefaultCondition, cfg.DefaultHistory)
+	}
+	if cfg.MaxConcurrentReconciles != 4 || cfg.QPS != 50 || cfg.Burst != 100 {
+		t.Errorf("Expected concurrency 4 and rate limits 50/100, got %d and %v/%d", cfg.MaxConcurrentReconciles, cfg.QPS, cfg.Burst)
+	}
+	if cfg.RetryBaseDelay != 10*time.Millisecond || cfg.RetryMaxDelay != time.Minute || cfg.QueueQPS != DefaultControllerConfig.QueueQPS {
+		t.Errorf("Expected queue retry delays 10ms to 1m and default qps, got %v to %v and %v", cfg.RetryBaseDelay, cfg.RetryMaxDelay, cfg.QueueQPS)
+	}
+	if cfg.PollQPSPerKind != 2 || cfg.PollBurstPerKind != DefaultControllerConfig.PollBurstPerKind {
+		t.Errorf("Expected per-kind poll rate 2 and default burst, got %v/%d", cfg.PollQPSPerKind, cfg.PollBurstPerKind)
+	}
+	if cfg.Enabled(FeatureCloudEvents) {
+		t.Errorf("Expected feature gate %s to be disabled", FeatureCloudEvents)
+	}
+	if strings.Join(cfg.CachedKinds, ",") != "v1/ConfigMap,apps/v1/Deployment" {
+		t.Errorf("Expected cached kinds v1/ConfigMap and apps/v1/Deployment, got %v", cfg.CachedKinds)
+	}
+	if strings.Join(cfg.EventSinkAllowedHosts, ",") != "*.knative.svc" {
+		t.Errorf("Expected allowed event sink hosts *.knative.svc, got %v", cfg.EventSinkAllowedHosts)
+	}
+	if cfg.EventSinkURL != "http://sink" || cfg.HashKeyScope != HashKeyScopeSecrets {
+		t.Errorf("Expected unset fields to keep their base values, got %+v", cfg)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "wrong kind",
+			content:  "apiVersion: v1\nkind: ConfigMap\n",
+			expected: []string{"apiVersion and kind"},
+		},
+		{
+			name:     "unknown field",
+			content:  "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\npollIntervall: 30s\n",
+			expected: []string{"pollIntervall"},
+		},
+		{
+			name:     "inverted poll interval bounds",
+			content:  "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\nminPollInterval: 5m\nmaxPollInterval: 1m\n",
+			expected: []string{"minPollInterval 5m0s is greater than maxPollInterval 1m0s"},
+		},
+		{
+			name: "all invalid settings are reported",
+			content: `
+apiVersion: config.changejob.dev/v1alpha
+kind: ControllerConfiguration
+pollInterval: 0s
+pollJitter: 2
+defaults:
+  condition: Some
+  history: 0
+concurrency:
+  maxConcurrentReconciles: 0
+rateLimits:
+  qps: -1
+  queue:
+    baseDelay: 1m
+    maxDelay: 1s
+  pollPerKind:
+    burst: 0
+eventSink:
+  mode: text
+hashKey:
+  secret: no-namespace
+sharding:
+  shards: -1
+cache:
+  kinds: [ConfigMap]
+featureGates:
+  Unknown: true
+`,
+			expected: []string{
+				"pollInterval", "pollJitter", "defaults.condition", "defaults.history", "concurrency.maxConcurrentReconciles",
+				"rateLimits.qps", "rateLimits.queue.baseDelay", "rateLimits.pollPerKind.burst", "eventSink.mode", "hashKey.secret", "sharding.shards", "cache.kinds", `unknown feature gate "Unknown"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, t.TempDir(), tt.content)
+			_, err := LoadFile(path, DefaultControllerConfig)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			for _, msg := range tt.expected {
+				if !strings.Contains(err.Error(), msg) {
+					t.Errorf("Expected error to contain %q, got %v", msg, err)
+				}
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"), DefaultControllerConfig); err == nil {
+		t.Error("Expected an error for a missing file, got nil")
+	}
+}
+
+func TestKeepStartupSettings(t *testing.T) {
+	running := DefaultControllerConfig
+	loaded := running
+	loaded.PollInterval = 10 * time.Second
+	loaded.MaxConcurrentReconciles = 8
+	loaded.WatchNamespaces = []string{"team-a"}
+
+	cfg, changed := KeepStartupSettings(running, loaded)
+	if cfg.PollInterval != 10*time.Second {
+		t.Errorf("Expected PollInterval to be reloaded, got %v", cfg.PollInterval)
+	}
+	if cfg.MaxConcurrentReconciles != running.MaxConcurrentReconciles || len(cfg.WatchNamespaces) != 0 {
+		t.Errorf("Expected startup settings to keep their running values, got %+v", cfg)
+	}
+	if strings.Join(changed, ",") != "concurrency.maxConcurrentReconciles,namespaces.watch" {
+		t.Errorf("Expected changed startup settings to be reported, got %v", changed)
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\npollInterval: 30s\n")
+
+	store, err := LoadStore(path, DefaultControllerConfig)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.Get().PollInterval != 30*time.Second {
+		t.Errorf("Expected PollInterval to be 30s, got %v", store.Get().PollInterval)
+	}
+
+	// Fields removed from the file fall back to the base configuration
+	writeConfigFile(t, dir, "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\ndefaults:\n  history: 3\n")
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg := store.Get(); cfg.PollInterval != DefaultControllerConfig.PollInterval || cfg.DefaultHistory != 3 {
+		t.Errorf("Expected PollInterval %v and history 3, got %v and %d", DefaultControllerConfig.PollInterval, cfg.PollInterval, cfg.DefaultHistory)
+	}
+
+	// An invalid file keeps the previous configuration
+	writeConfigFile(t, dir, "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\npollInterval: -1s\n")
+	if _, err := store.Reload(); err == nil {
+		t.Error("Expected an error, got nil")
+	}
+	if store.Get().DefaultHistory != 3 {
+		t.Errorf("Expected the previous configuration to be kept, got history %d", store.Get().DefaultHistory)
+	}
+
+	// Empty values clear the base configuration
+	base := DefaultControllerConfig
+	base.EventSinkURL = "http://sink"
+	base.EventSinkAllowedHosts = []string{"sink"}
+	writeConfigFile(t, dir, "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\n")
+	store, err = LoadStore(path, base)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	writeConfigFile(t, dir, "apiVersion: config.changejob.dev/v1alpha\nkind: ControllerConfiguration\neventSink:\n  url: \"\"\n  allowedHosts: []\n")
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg := store.Get(); cfg.EventSinkURL != "" || len(cfg.EventSinkAllowedHosts) != 0 {
+		t.Errorf("Expected the event sink URL and allowed hosts to be cleared, got %q and %v", cfg.EventSinkURL, cfg.EventSinkAllowedHosts)
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Store holds the current ControllerConfig and reloads it when the configuration file changes
+type Store struct {
+	path string
+	base ControllerConfig
+
+	mu  sync.RWMutex
+	cfg ControllerConfig
+}
+
+// NewStore returns a store with a fixed configuration
+func NewStore(cfg ControllerConfig) *Store {
+	return &Store{base: cfg, cfg: cfg}
+}
+
+// LoadStore loads the configuration file on top of base, which is kept to re-apply the file on every reload
+func LoadStore(path string, base ControllerConfig) (*Store, error) {
+	cfg, err := LoadFile(path, base)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path, base: base, cfg: cfg}, nil
+}
+
+// Get returns the current configuration
+func (s *Store) Get() ControllerConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Reload re-reads the configuration file, keeping the current configuration if it is invalid.
+// Settings only applied at startup keep their running values and are returned.
+func (s *Store) Reload() ([]string, error) {
+	if s.path == "" {
+		return nil, nil
+	}
+	loaded, err := LoadFile(s.path, s.base)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg, ignored := KeepStartupSettings(s.cfg, loaded)
+	s.cfg = cfg
+	return ignored, nil
+}
+
+// NeedLeaderElection reloads the configuration on every replica, webhooks and shards are served by all of them
+func (s *Store) NeedLeaderElection() bool {
+	return false
+}
+
+// Start watches the configuration file until the context is done, it implements manager.Runnable
+func (s *Store) Start(ctx context.Context) error {
+	if s.path == "" {
+		<-ctx.Done()
+		return nil
+	}
+	log := logf.FromContext(ctx).WithName("config")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to watch config file: %w", err)
+	}
+	defer watcher.Close() //nolint:errcheck
+
+	// Watch the directory, ConfigMap volumes replace files through a symlink swap
+	if err := watcher.Add(filepath.Dir(s.path)); err != nil {
+		return fmt.Errorf("unable to watch config file: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error(err, "Config file watch error")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			ignored, err := s.Reload()
+			if err != nil {
+				log.Error(err, "Keeping previous configuration", "path", s.path)
+				continue
+			}
+			if len(ignored) > 0 {
+				log.Info("Settings changed that require a restart to apply", "settings", ignored)
+			}
+			log.V(1).Info("Reloaded configuration", "path", s.path)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+// PruneTriggerHistory returns the trigger times within the rate limit window, nil without a rate limit
+func PruneTriggerHistory(limit *triggersv1alpha.TriggerRateLimit, history []metav1.Time, now time.Time) []metav1.Time {
+	if limit == nil {
+		return nil
+	}
+	start := now.Add(-limit.Window.Duration)
+	var pruned []metav1.Time
+	for _, t := range history {
+		if t.After(start) {
+			pruned = append(pruned, t)
+		}
+	}
+	return pruned
+}
+
+// ValidateRateLimit checks the rate limit the CRD schema validates, which may have been bypassed
+func ValidateRateLimit(limit *triggersv1alpha.TriggerRateLimit) error {
+	if limit == nil {
+		return nil
+	}
+	if limit.MaxTriggers < 1 {
+		return fmt.Errorf("rateLimit.maxTriggers must be at least 1, got %d", limit.MaxTriggers)
+	}
+	return nil
+}
+
+// TriggerBudget reports whether the rate limit allows another job given the pruned trigger history and, when it does
+// not, when the oldest counted job leaves the window. An invalid rate limit, see ValidateRateLimit, allows every job.
+func TriggerBudget(limit *triggersv1alpha.TriggerRateLimit, history []metav1.Time, now time.Time) (bool, time.Time) {
+	if limit == nil || limit.MaxTriggers < 1 || len(history) < int(limit.MaxTriggers) {
+		return true, time.Time{}
+	}
+	// The job that frees a slot is the oldest one of the last maxTriggers
+	oldest := history[len(history)-int(limit.MaxTriggers)]
+	next := oldest.Add(limit.Window.Duration)
+	return !next.After(now), next
+}
+
+// Record whether the rate limit budget is exhausted as the RateLimited condition, removed without a rate limit
+func setRateLimited(changeJob *triggersv1alpha.ChangeTriggeredJob, now time.Time) {
+	limit := changeJob.Spec.RateLimit
+	if limit == nil {
+		meta.RemoveStatusCondition(&changeJob.Status.Conditions, triggersv1alpha.ConditionTypeRateLimited)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               triggersv1alpha.ConditionTypeRateLimited,
+		Status:             metav1.ConditionFalse,
+		Reason:             triggersv1alpha.ReasonWithinBudget,
+		Message:            fmt.Sprintf("%d of %d jobs triggered in the last %s", len(changeJob.Status.TriggerHistory), limit.MaxTriggers, limit.Window.Duration),
+		ObservedGeneration: changeJob.Generation,
+	}
+	if allowed, next := TriggerBudget(limit, changeJob.Status.TriggerHistory, now); !allowed {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = triggersv1alpha.ReasonBudgetExhausted
+		condition.Message = fmt.Sprintf("%d of %d jobs triggered in the last %s, changes are %s until %s", len(changeJob.Status.TriggerHistory),
+			limit.MaxTriggers, limit.Window.Duration, RateLimitAction(limit.Policy), next.UTC().Format(time.RFC3339))
+	}
+	meta.SetStatusCondition(&changeJob.Status.Conditions, condition)
+}
+
+// RateLimitAction describes what happens to changes beyond the rate limit
+func RateLimitAction(policy triggersv1alpha.RateLimitPolicy) string {
+	if policy == triggersv1alpha.RateLimitPolicyQueue {
+		return "queued"
+	}
+	return "dropped"
+}
@@ -19,17 +19,37 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/cloudevents"
 	"github.com/nusnewob/kube-changejob/internal/config"
+	"github.com/nusnewob/kube-changejob/internal/policy"
+	"github.com/nusnewob/kube-changejob/internal/sharding"
 )
 
 // ChangeTriggeredJobReconciler reconciles a ChangeTriggeredJob object
@@ -37,7 +57,28 @@ type ChangeTriggeredJobReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Config config.ControllerConfig
-	Log    logr.Logger
+	// Optional: reloadable configuration, takes precedence over Config
+	ConfigStore *config.Store
+	Log         logr.Logger
+	// RestConfig is used to build clients impersonating spec.serviceAccountName
+	RestConfig *rest.Config
+	// Optional: HMAC key for field hashes, see config.ControllerConfig.HashKeyScope
+	HashKeys *HashKeyProvider
+	// Optional: request budget for polls of every watched GroupVersionKind
+	PollRateLimiter *GVKRateLimiter
+	// Optional: reconcile only the ChangeTriggeredJobs of this replica's shards
+	Sharder *sharding.Sharder
+	// Optional: informer cache for the watched kinds in config.ControllerConfig.CachedKinds
+	Cache cache.Cache
+	// Optional: records Kubernetes Events, e.g., when a watched field flaps
+	Recorder events.EventRecorder
+	// Optional: delivers CloudEvents in the background, no CloudEvents are sent without it
+	Events *cloudevents.Dispatcher
+
+	impersonatedClients sync.Map
+	cachedKinds         map[schema.GroupVersionKind]bool
+	// CloudEvents of the running reconcile by ChangeTriggeredJob, queued once its status is written
+	pendingEvents sync.Map
 }
 
 const (
@@ -54,27 +95,130 @@ var log = logf.Log.WithName("ChangeTriggeredJob")
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // Watched resources
 // +kubebuilder:rbac:groups="*",resources="*",verbs=get;watch
+// Poll and create jobs as spec.serviceAccountName
+// +kubebuilder:rbac:groups="",resources=serviceaccounts;groups,verbs=impersonate
+// Load the hash key
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// Enforce ChangeJobPolicies
+// +kubebuilder:rbac:groups=triggers.changejob.dev,resources=changejobpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// Shard membership
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update;delete
+// Report flapping fields
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.24.1/pkg/reconcile
 func (r *ChangeTriggeredJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cfg := r.config()
+
+	// Leave the ChangeTriggeredJobs of other shards to their replicas, a rebalance requeues every ChangeTriggeredJob
+	if r.Sharder != nil && !r.Sharder.Owns(req.String()) {
+		return ctrl.Result{}, nil
+	}
+
+	// Events of an earlier reconcile that failed before writing status are detected again
+	r.pendingEvents.Delete(req.NamespacedName)
+
 	var changeJob triggersv1alpha.ChangeTriggeredJob
 	if err := r.Get(ctx, req.NamespacedName, &changeJob); err != nil {
 		log.Error(err, "unable to fetch ChangeTriggeredJob")
-		return ctrl.Result{RequeueAfter: r.Config.PollInterval}, client.IgnoreNotFound(err)
+		return ctrl.Result{RequeueAfter: cfg.PollInterval}, client.IgnoreNotFound(err)
+	}
+	// Status changes are written as a patch against the object as read
+	original := changeJob.DeepCopy()
+
+	// Poll and create jobs as the ChangeTriggeredJob's ServiceAccount, if any
+	c, err := r.clientFor(&changeJob)
+	if err != nil {
+		log.Error(err, "unable to build client")
+		return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, err
+	}
+
+	// Stop polling while the ChangeTriggeredJob violates a ChangeJobPolicy, a policy change requeues it
+	if cfg.EnforcePolicies {
+		violations, invalid, err := policy.Check(ctx, r.Client, &changeJob)
+		if err != nil {
+			log.Error(err, "unable to check policies")
+			return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, err
+		}
+		// Policies with an invalid namespaceSelector are skipped, not enforced
+		setPoliciesSkipped(&changeJob, invalid)
+		if len(violations) > 0 {
+			log.Info("ChangeTriggeredJob violates policy", "name", changeJob.Name, "violations", violations.ToAggregate().Error())
+			return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonPolicyViolation, violations.ToAggregate())
+		}
+	} else {
+		setPoliciesSkipped(&changeJob, nil)
 	}
 
 	// Validate JobTemplate
-	if err := ValidateJobTemplate(ctx, r.Client, changeJob.Namespace, changeJob.Spec.JobTemplate); err != nil {
+	if err := ValidateJobTemplate(ctx, c, changeJob.Namespace, changeJob.Spec.JobTemplate); err != nil {
+		if apierrors.IsForbidden(err) {
+			log.Error(err, "not allowed to create jobs")
+			return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonPermissionDenied, err)
+		}
 		log.Error(err, "invalid job template")
 		// Don't requeue, as this is a configuration error
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonInvalidJobTemplate, err)
+	}
+
+	// Validate the trigger expression, webhooks may have been bypassed
+	if ptr.Deref(changeJob.Spec.Condition, "") == triggersv1alpha.TriggerConditionExpression {
+		if _, err := ParseTriggerExpression(&changeJob.Spec); err != nil {
+			log.Error(err, "invalid trigger expression")
+			// Don't requeue, as this is a configuration error
+			return ctrl.Result{}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonInvalidTriggerExpression, err)
+		}
+	}
+
+	// Validate the rate limit, the CRD schema may have been bypassed
+	if err := ValidateRateLimit(changeJob.Spec.RateLimit); err != nil {
+		log.Error(err, "invalid rate limit")
+		// Don't requeue, as this is a configuration error
+		return ctrl.Result{}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonInvalidRateLimit, err)
+	}
+
+	now := time.Now()
+	inWindow, nextWindow, err := NextTriggerWindow(changeJob.Spec.TriggerWindows, now)
+	if err != nil {
+		log.Error(err, "invalid trigger windows")
+		// Don't requeue, as this is a configuration error
+		return ctrl.Result{}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonInvalidTriggerWindows, err)
+	}
+
+	// Evaluate the schedule alongside the polls, a due run triggers a job even when nothing changed
+	var scheduleDue time.Time
+	changeJob.Status.NextScheduleTime = nil
+	if changeJob.Spec.Schedule != "" {
+		schedule, err := ParseSchedule(changeJob.Spec.Schedule)
+		if err != nil {
+			log.Error(err, "invalid schedule")
+			// Don't requeue, as this is a configuration error
+			return ctrl.Result{}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonInvalidSchedule, err)
+		}
+		last := changeJob.CreationTimestamp.Time
+		if changeJob.Status.LastScheduleTime != nil {
+			last = changeJob.Status.LastScheduleTime.Time
+		}
+		if last.IsZero() {
+			last = now
+		}
+		var next time.Time
+		scheduleDue, next = DueSchedule(schedule, last, changeJob.Spec.StartingDeadlineSeconds, now)
+		if !next.IsZero() {
+			changeJob.Status.NextScheduleTime = &metav1.Time{Time: next}
+		}
 	}
 
-	changed, updatedStatuses, err := r.pollResources(ctx, &changeJob)
+	reason, updatedStatuses, err := r.pollResources(ctx, c, &changeJob)
 	if err != nil {
+		if apierrors.IsForbidden(err) {
+			log.Error(err, "not allowed to poll resources")
+			return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonPermissionDenied, err)
+		}
 		log.Error(err, "unable to poll resources")
-		return ctrl.Result{RequeueAfter: r.Config.PollInterval}, err
+		return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, err
 	}
 
 	// Initialize resource hashes on first run or update status
@@ -86,21 +230,142 @@ func (r *ChangeTriggeredJobReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// Always update hashes
 	changeJob.Status.ResourceHashes = updatedStatuses
 
-	if changed {
-		// Check if we should trigger (first time or after cooldown)
-		if changeJob.Status.LastTriggeredTime == nil || time.Since(changeJob.Status.LastTriggeredTime.Time) > changeJob.Spec.Cooldown.Duration {
-			log.Info("ChangeTriggeredJob triggered", "name", changeJob.Name)
-			if _, err := r.triggerJob(ctx, &changeJob); err != nil {
-				log.Error(err, "unable to trigger job")
-				return ctrl.Result{RequeueAfter: r.Config.PollInterval}, err
+	// A new acknowledgement resumes triggering after flapping and forgets the changes counted so far
+	acknowledged := changeJob.Annotations[triggersv1alpha.FlappingAcknowledgedAtAnnotation]
+	if acknowledged != "" && acknowledged != changeJob.Status.LastHandledFlappingAcknowledgement {
+		log.Info("Flapping acknowledged", "name", changeJob.Name, "autoSuspended", changeJob.Status.AutoSuspended)
+		changeJob.Status.LastHandledFlappingAcknowledgement = acknowledged
+		changeJob.Status.AutoSuspended = false
+		clearFieldChanges(changeJob.Status.ResourceHashes)
+	}
+	r.setFlapping(&changeJob, FlappingFields(changeJob.Spec.FlappingDetection, changeJob.Status.ResourceHashes))
+
+	changed := reason != ""
+	suspended := ptr.Deref(changeJob.Spec.Suspend, false) || changeJob.Status.AutoSuspended
+	if suspended {
+		// Changes accumulated or held while suspended never trigger a job on resume
+		clearChanges(changeJob.Status.ResourceHashes)
+		changeJob.Status.PendingTriggerReason = ""
+		changeJob.Status.NextEligibleTime = nil
+	}
+	if changed && suspended {
+		log.Info("ChangeTriggeredJob suspended, not triggering", "name", changeJob.Name)
+		changed = false
+	}
+
+	// A job held outside the trigger windows triggers once one opens
+	if !changed && changeJob.Status.PendingTriggerReason != "" {
+		changed = true
+		reason = changeJob.Status.PendingTriggerReason
+	}
+
+	// A new trigger request runs a job regardless of changes, cooldown, suspension and trigger windows
+	requested := changeJob.Annotations[triggersv1alpha.TriggerRequestedAtAnnotation]
+	manual := requested != "" && requested != changeJob.Status.LastHandledTriggerRequest
+
+	if changed && !manual {
+		if inWindow {
+			changeJob.Status.NextEligibleTime = nil
+		} else {
+			if changeJob.Status.PendingTriggerReason == "" {
+				log.Info("Outside the trigger windows, holding the job until the next one opens", "name", changeJob.Name, "next", nextWindow)
 			}
+			changeJob.Status.PendingTriggerReason = reason
+			changeJob.Status.NextEligibleTime = nil
+			if !nextWindow.IsZero() {
+				changeJob.Status.NextEligibleTime = &metav1.Time{Time: nextWindow}
+			}
+			changed = false
+		}
+	}
+
+	// Scheduled runs ignore cooldown and trigger windows, runs due while suspended are skipped
+	scheduled := !scheduleDue.IsZero()
+	if scheduled && suspended {
+		log.Info("ChangeTriggeredJob suspended, skipping the scheduled run", "name", changeJob.Name, "scheduled", scheduleDue)
+		changeJob.Status.LastScheduleTime = &metav1.Time{Time: scheduleDue}
+		scheduled = false
+	}
+
+	// First time or after cooldown
+	cooledDown := changeJob.Status.LastTriggeredTime == nil || time.Since(changeJob.Status.LastTriggeredTime.Time) > changeJob.Spec.Cooldown.Duration
+
+	// Jobs triggered by changes are limited to the rate limit budget, excess changes are dropped or queued
+	limit := changeJob.Spec.RateLimit
+	changeJob.Status.TriggerHistory = PruneTriggerHistory(limit, changeJob.Status.TriggerHistory, now)
+	if changed && cooledDown && !manual {
+		if allowed, nextAllowed := TriggerBudget(limit, changeJob.Status.TriggerHistory, now); !allowed {
+			if limit.Policy == triggersv1alpha.RateLimitPolicyQueue {
+				if changeJob.Status.PendingTriggerReason == "" {
+					log.Info("Rate limit exhausted, holding the job until the budget allows it", "name", changeJob.Name, "next", nextAllowed)
+				}
+				changeJob.Status.PendingTriggerReason = reason
+				changeJob.Status.NextEligibleTime = &metav1.Time{Time: nextAllowed}
+			} else {
+				log.Info("Rate limit exhausted, dropping the changes", "name", changeJob.Name, "next", nextAllowed)
+				changeJob.Status.PendingTriggerReason = ""
+				changeJob.Status.NextEligibleTime = nil
+				clearChanges(changeJob.Status.ResourceHashes)
+			}
+			changed = false
+		}
+	}
+
+	var triggeredBy triggersv1alpha.TriggeredBy
+	switch {
+	case manual:
+		triggeredBy, reason = triggersv1alpha.TriggeredByRequested, fmt.Sprintf("Requested at %s", requested)
+	case changed && cooledDown:
+		triggeredBy = triggersv1alpha.TriggeredByChanged
+	case scheduled:
+		triggeredBy, reason = triggersv1alpha.TriggeredByScheduled, fmt.Sprintf("Scheduled run at %s", scheduleDue.UTC().Format(time.RFC3339))
+	}
+
+	if triggeredBy != "" {
+		log.Info("ChangeTriggeredJob triggered", "name", changeJob.Name, "triggeredBy", triggeredBy)
+		if _, err := r.triggerJob(ctx, c, &changeJob, triggeredBy, reason); err != nil {
+			if apierrors.IsForbidden(err) {
+				log.Error(err, "not allowed to create jobs")
+				return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, r.setDegraded(ctx, original, &changeJob, triggersv1alpha.ReasonPermissionDenied, err)
+			}
+			log.Error(err, "unable to trigger job")
+			return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, err
+		}
+		if manual {
+			changeJob.Status.LastHandledTriggerRequest = requested
 		}
+		if scheduled {
+			// Any job covers the scheduled run
+			changeJob.Status.LastScheduleTime = &metav1.Time{Time: scheduleDue}
+		}
+		if limit != nil {
+			changeJob.Status.TriggerHistory = append(changeJob.Status.TriggerHistory, metav1.Time{Time: now})
+		}
+		changeJob.Status.LastTriggerReason = reason
+		changeJob.Status.LastTriggeredBy = triggeredBy
+		changeJob.Status.PendingTriggerReason = ""
+		changeJob.Status.NextEligibleTime = nil
+		clearChanges(changeJob.Status.ResourceHashes)
 	}
+	setRateLimited(&changeJob, now)
+
+	message := fmt.Sprintf("Watching %d resources", len(changeJob.Spec.Resources))
+	if suspended {
+		message = fmt.Sprintf("Suspended, watching %d resources", len(changeJob.Spec.Resources))
+	}
+
+	meta.SetStatusCondition(&changeJob.Status.Conditions, metav1.Condition{
+		Type:               triggersv1alpha.ConditionTypeDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             triggersv1alpha.ReasonReconciled,
+		Message:            message,
+		ObservedGeneration: changeJob.Generation,
+	})
 
 	// Always update status, including job history and latest job info
-	if err := r.updateStatus(ctx, &changeJob); err != nil {
+	if err := r.updateStatus(ctx, original, &changeJob); err != nil {
 		log.Error(err, "unable to update status")
-		return ctrl.Result{RequeueAfter: r.Config.PollInterval}, err
+		return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, err
 	}
 
 	// Delete old jobs on every reconcile
@@ -121,7 +386,7 @@ func (r *ChangeTriggeredJobReconciler) Reconcile(ctx context.Context, req ctrl.R
 	}
 
 	// Always requeue to keep polling
-	return ctrl.Result{RequeueAfter: r.Config.PollInterval}, nil
+	return ctrl.Result{RequeueAfter: r.requeueAfter(&changeJob)}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -137,8 +402,79 @@ func (r *ChangeTriggeredJobReconciler) SetupWithManager(mgr ctrl.Manager) error
 		return fmt.Errorf("failed to setup field indexer for Jobs: %w", err)
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	cfg := r.config()
+	if r.Cache != nil {
+		// Start the informers with the manager, so polls never wait for a cache to sync
+		r.cachedKinds = make(map[schema.GroupVersionKind]bool, len(cfg.CachedKinds))
+		for _, kind := range cfg.CachedKinds {
+			gvk, err := config.ParseKind(kind)
+			if err != nil {
+				return err
+			}
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(gvk)
+			if _, err := r.Cache.GetInformer(context.Background(), obj); err != nil {
+				return fmt.Errorf("failed to set up cache for %s: %w", kind, err)
+			}
+			r.cachedKinds[gvk] = true
+		}
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&triggersv1alpha.ChangeTriggeredJob{}).
-		Named("changetriggeredjob").
-		Complete(r)
+		Named("changetriggeredjob")
+	options := controller.Options{MaxConcurrentReconciles: cfg.MaxConcurrentReconciles}
+	if cfg.RetryBaseDelay > 0 && cfg.QueueQPS > 0 {
+		options.RateLimiter = NewQueueRateLimiter(cfg)
+	}
+	if r.Sharder != nil {
+		// Every replica reconciles its own shards
+		options.NeedLeaderElection = ptr.To(false)
+		b = b.WatchesRawSource(source.Channel(r.Sharder.Rebalanced, handler.EnqueueRequestsFromMapFunc(r.allChangeTriggeredJobs)))
+	}
+	b = b.WithOptions(options)
+	if cfg.EnforcePolicies {
+		// Re-evaluate every ChangeTriggeredJob when a policy changes
+		b = b.Watches(&triggersv1alpha.ChangeJobPolicy{}, handler.EnqueueRequestsFromMapFunc(r.allChangeTriggeredJobs))
+		// Re-evaluate the ChangeTriggeredJobs in a namespace when its labels change, as policies select namespaces by label
+		b = b.Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.namespaceChangeTriggeredJobs),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}))
+	}
+	return b.Complete(r)
+}
+
+// config returns the current configuration
+func (r *ChangeTriggeredJobReconciler) config() config.ControllerConfig {
+	if r.ConfigStore != nil {
+		return r.ConfigStore.Get()
+	}
+	return r.Config
+}
+
+// allChangeTriggeredJobs maps any event to a request for every ChangeTriggeredJob
+func (r *ChangeTriggeredJobReconciler) allChangeTriggeredJobs(ctx context.Context, _ client.Object) []reconcile.Request {
+	var list triggersv1alpha.ChangeTriggeredJobList
+	if err := r.List(ctx, &list); err != nil {
+		log.Error(err, "unable to list ChangeTriggeredJobs")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, changeJob := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&changeJob)})
+	}
+	return requests
+}
+
+// namespaceChangeTriggeredJobs maps a Namespace to a request for every ChangeTriggeredJob in it
+func (r *ChangeTriggeredJobReconciler) namespaceChangeTriggeredJobs(ctx context.Context, namespace client.Object) []reconcile.Request {
+	var list triggersv1alpha.ChangeTriggeredJobList
+	if err := r.List(ctx, &list, client.InNamespace(namespace.GetName())); err != nil {
+		log.Error(err, "unable to list ChangeTriggeredJobs", "namespace", namespace.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, changeJob := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&changeJob)})
+	}
+	return requests
 }
@@ -3,23 +3,32 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/cloudevents"
 	"github.com/nusnewob/kube-changejob/internal/config"
+	"github.com/nusnewob/kube-changejob/internal/sharding"
 )
 
 var _ = Describe("ChangeTriggeredJob Controller", func() {
@@ -1806,5 +1815,1288 @@ var _ = Describe("ChangeTriggeredJob Controller", func() {
 				return len(jobList.Items)
 			}, time.Second*10, time.Millisecond*500).Should(Equal(1))
 		})
+
+		It("Should emit CloudEvents for detected changes and triggered jobs", func() {
+			By("Starting a CloudEvents sink")
+			var mu sync.Mutex
+			var received []string
+			sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				received = append(received, r.Header.Get("ce-type"))
+			}))
+			defer sink.Close()
+
+			By("Creating a ChangeTriggeredJob with an event sink")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{
+							APIVersion: "v1",
+							Kind:       testKindConfigMap,
+							Name:       cmName,
+							Namespace:  ctjNamespace,
+							Fields:     []string{testDataConfig},
+						},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					Cooldown:  &metav1.Duration{Duration: 1 * time.Second},
+					EventSink: &triggersv1alpha.EventSink{URL: sink.URL, Mode: triggersv1alpha.EventSinkModeBinary},
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers: []corev1.Container{
+										{
+											Name:    testContainerName,
+											Image:   testImageBusybox,
+											Command: []string{testCmdEcho, testCmdHelloWorld},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+
+			By("Creating a ConfigMap")
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cmName,
+					Namespace: ctjNamespace,
+				},
+				Data: map[string]string{
+					testFieldConfig: testValueInitialValue,
+				},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			By("Allowing the sink host and starting the event dispatcher")
+			cfg := config.DefaultControllerConfig
+			cfg.EventSinkAllowedHosts = []string{"127.0.0.1"}
+			dispatcher := cloudevents.NewDispatcher(cloudevents.DefaultQueueSize)
+			dispatchCtx, stopDispatch := context.WithCancel(ctx)
+			defer stopDispatch()
+			go func() {
+				defer GinkgoRecover()
+				Expect(dispatcher.Start(dispatchCtx)).To(Succeed())
+			}()
+
+			controllerReconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: cfg,
+				Log:    logr.New(zap.New(zap.UseDevMode(true)).GetSink()),
+				Events: dispatcher,
+			}
+
+			By("First reconciliation - establishes baseline, no events")
+			_, err := controllerReconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			mu.Lock()
+			Expect(received).To(BeEmpty())
+			mu.Unlock()
+
+			By("Updating the watched field")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: cmName, Namespace: ctjNamespace}, cm)).Should(Succeed())
+			cm.Data[testFieldConfig] = testValueChanged
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+
+			By("Second reconciliation - emits change and trigger events")
+			_, err = controllerReconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() []string {
+				mu.Lock()
+				defer mu.Unlock()
+				return slices.Clone(received)
+			}).Should(ConsistOf(cloudevents.TypeResourceChanged, cloudevents.TypeJobTriggered))
+		})
+
+		It("Should report PermissionDenied when the ServiceAccount cannot create jobs", func() {
+			By("Creating a ChangeTriggeredJob running as a ServiceAccount without permissions")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{
+							APIVersion: "v1",
+							Kind:       testKindConfigMap,
+							Name:       cmName,
+							Namespace:  ctjNamespace,
+						},
+					},
+					Condition:          ptr.To(triggersv1alpha.TriggerConditionAny),
+					Cooldown:           &metav1.Duration{Duration: 1 * time.Second},
+					ServiceAccountName: "unprivileged",
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers: []corev1.Container{
+										{
+											Name:    testContainerName,
+											Image:   testImageBusybox,
+											Command: []string{testCmdEcho, testCmdHelloWorld},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+
+			controllerReconciler := &ChangeTriggeredJobReconciler{
+				Client:     k8sClient,
+				Scheme:     k8sClient.Scheme(),
+				Config:     config.DefaultControllerConfig,
+				Log:        logr.New(zap.New(zap.UseDevMode(true)).GetSink()),
+				RestConfig: cfg,
+			}
+
+			By("Reconciling as the ServiceAccount")
+			result, err := controllerReconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(config.DefaultControllerConfig.PollInterval))
+
+			By("Checking the Degraded condition")
+			updated := &triggersv1alpha.ChangeTriggeredJob{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}, updated)).Should(Succeed())
+			degraded := meta.FindStatusCondition(updated.Status.Conditions, triggersv1alpha.ConditionTypeDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+			Expect(degraded.Reason).To(Equal(triggersv1alpha.ReasonPermissionDenied))
+			Expect(degraded.Message).To(ContainSubstring("system:serviceaccount:" + ctjNamespace + ":unprivileged"))
+		})
+
+		It("Should re-baseline without triggering when the hash key changes", func() {
+			By("Creating a ConfigMap and the hash key Secret")
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cmName,
+					Namespace: ctjNamespace,
+				},
+				Data: map[string]string{
+					testFieldConfig: testValueInitialValue,
+				},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+			keySecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName + "-hash-key",
+					Namespace: ctjNamespace,
+				},
+				Data: map[string][]byte{
+					HashKeySecretKey: []byte("0123456789abcdef0123456789abcdef"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, keySecret)).Should(Succeed())
+			defer func() { _ = k8sClient.Delete(ctx, keySecret) }()
+
+			By("Creating a ChangeTriggeredJob")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{
+							APIVersion: "v1",
+							Kind:       testKindConfigMap,
+							Name:       cmName,
+							Namespace:  ctjNamespace,
+							Fields:     []string{testDataConfig},
+						},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					Cooldown:  &metav1.Duration{Duration: 1 * time.Second},
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers: []corev1.Container{
+										{
+											Name:    testContainerName,
+											Image:   testImageBusybox,
+											Command: []string{testCmdEcho, testCmdHelloWorld},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+
+			cfg := config.DefaultControllerConfig
+			cfg.HashKeyScope = config.HashKeyScopeAll
+			controllerReconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: cfg,
+				Log:    logr.New(zap.New(zap.UseDevMode(true)).GetSink()),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Establishing a plain SHA256 baseline")
+			_, err := controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Enabling the hash key")
+			controllerReconciler.HashKeys = &HashKeyProvider{
+				Reader: k8sClient,
+				Secret: types.NamespacedName{Name: keySecret.Name, Namespace: ctjNamespace},
+			}
+			_, err = controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			updated := &triggersv1alpha.ChangeTriggeredJob{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, updated)).Should(Succeed())
+			Expect(updated.Status.ResourceHashes).To(HaveLen(1))
+			Expect(updated.Status.ResourceHashes[0].KeyID).NotTo(BeEmpty())
+
+			By("Verifying no job was created by the key change")
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).Should(Succeed())
+			Expect(jobList.Items).To(BeEmpty())
+
+			By("Updating the watched field")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: cmName, Namespace: ctjNamespace}, cm)).Should(Succeed())
+			cm.Data[testFieldConfig] = testValueChanged
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Verifying a job was created for the real change")
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).Should(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+		})
+
+		It("Should mark the ChangeTriggeredJob Degraded when a policy tightens", func() {
+			By("Creating a ConfigMap and a ChangeTriggeredJob")
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cmName,
+					Namespace: ctjNamespace,
+				},
+				Data: map[string]string{
+					testFieldConfig: testValueInitialValue,
+				},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{
+							APIVersion: "v1",
+							Kind:       testKindConfigMap,
+							Name:       cmName,
+							Namespace:  ctjNamespace,
+							Fields:     []string{testDataConfig},
+						},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					Cooldown:  &metav1.Duration{Duration: 1 * time.Second},
+					History:   ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers: []corev1.Container{
+										{
+											Name:    testContainerName,
+											Image:   testImageBusybox,
+											Command: []string{testCmdEcho, testCmdHelloWorld},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+
+			controllerReconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+				Log:    logr.New(zap.New(zap.UseDevMode(true)).GetSink()),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Reconciling without policies")
+			_, err := controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Skipping a policy with an invalid namespaceSelector")
+			broken := &triggersv1alpha.ChangeJobPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: ctjName + "-broken"},
+				Spec: triggersv1alpha.ChangeJobPolicySpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "tenant", Operator: metav1.LabelSelectorOpIn},
+					}},
+					MinCooldown: &metav1.Duration{Duration: time.Hour},
+				},
+			}
+			Expect(k8sClient.Create(ctx, broken)).Should(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			skipped := &triggersv1alpha.ChangeTriggeredJob{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, skipped)).Should(Succeed())
+			condition := meta.FindStatusCondition(skipped.Status.Conditions, triggersv1alpha.ConditionTypePoliciesSkipped)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(triggersv1alpha.ReasonInvalidPolicy))
+			Expect(condition.Message).To(ContainSubstring(broken.Name))
+			Expect(meta.IsStatusConditionFalse(skipped.Status.Conditions, triggersv1alpha.ConditionTypeDegraded)).To(BeTrue())
+
+			By("Removing the condition once the policy is gone")
+			Expect(k8sClient.Delete(ctx, broken)).Should(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, req.NamespacedName, skipped)).Should(Succeed())
+			Expect(meta.FindStatusCondition(skipped.Status.Conditions, triggersv1alpha.ConditionTypePoliciesSkipped)).To(BeNil())
+
+			By("Creating a policy requiring a longer cooldown")
+			policy := &triggersv1alpha.ChangeJobPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: ctjName},
+				Spec: triggersv1alpha.ChangeJobPolicySpec{
+					MinCooldown: &metav1.Duration{Duration: time.Hour},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).Should(Succeed())
+			defer func() { _ = k8sClient.Delete(ctx, policy) }()
+
+			_, err = controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			updated := &triggersv1alpha.ChangeTriggeredJob{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, updated)).Should(Succeed())
+			degraded := meta.FindStatusCondition(updated.Status.Conditions, triggersv1alpha.ConditionTypeDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+			Expect(degraded.Reason).To(Equal(triggersv1alpha.ReasonPolicyViolation))
+			Expect(degraded.Message).To(ContainSubstring("spec.cooldown"))
+
+			By("Verifying changes do not trigger jobs while the policy is violated")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: cmName, Namespace: ctjNamespace}, cm)).Should(Succeed())
+			cm.Data[testFieldConfig] = testValueChanged
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).Should(Succeed())
+			Expect(jobList.Items).To(BeEmpty())
+
+			By("Mapping a Namespace to the ChangeTriggeredJobs in it, so label changes re-evaluate policies")
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ctjNamespace}}
+			Expect(controllerReconciler.namespaceChangeTriggeredJobs(ctx, namespace)).To(ContainElement(req))
+			other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}
+			Expect(controllerReconciler.namespaceChangeTriggeredJobs(ctx, other)).NotTo(ContainElement(req))
+		})
+
+		It("Should requeue at the ChangeTriggeredJob's poll interval with jitter", func() {
+			cfg := config.DefaultControllerConfig
+			cfg.PollJitter = 0.5
+			reconciler := &ChangeTriggeredJobReconciler{Config: cfg}
+			changeJob := &triggersv1alpha.ChangeTriggeredJob{}
+
+			By("Using the controller poll interval when unset")
+			Expect(reconciler.requeueAfter(changeJob)).To(BeNumerically("~", 75*time.Second, 15*time.Second))
+
+			By("Using spec.pollInterval when set")
+			changeJob.Spec.PollInterval = &metav1.Duration{Duration: 20 * time.Second}
+			Expect(reconciler.requeueAfter(changeJob)).To(BeNumerically("~", 25*time.Second, 5*time.Second))
+
+			By("Clamping spec.pollInterval to the controller bounds")
+			changeJob.Spec.PollInterval = &metav1.Duration{Duration: time.Second}
+			Expect(reconciler.requeueAfter(changeJob)).To(BeNumerically("~", 12500*time.Millisecond, 2500*time.Millisecond))
+
+			By("Spreading requeues of identical ChangeTriggeredJobs")
+			intervals := make(map[time.Duration]struct{})
+			for range 10 {
+				intervals[reconciler.requeueAfter(changeJob)] = struct{}{}
+			}
+			Expect(len(intervals)).To(BeNumerically(">", 1))
+
+			By("Disabling jitter")
+			reconciler.Config.PollJitter = 0
+			Expect(reconciler.requeueAfter(changeJob)).To(Equal(10 * time.Second))
+		})
+
+		It("Should skip ChangeTriggeredJobs owned by other shards", func() {
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client:  k8sClient,
+				Scheme:  k8sClient.Scheme(),
+				Config:  config.DefaultControllerConfig,
+				Sharder: sharding.NewSharder(k8sClient, k8sClient, ctjNamespace, "test", "replica-a", 4),
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Skipping every ChangeTriggeredJob before the shard members are known")
+			result, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			By("Joining the shard group as its only member, without a handoff delay")
+			reconciler.Sharder.Handoff = 0
+			sharderCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			Expect(reconciler.Sharder.Start(sharderCtx)).To(Succeed())
+			Expect(reconciler.Sharder.Members()).To(Equal([]string{"replica-a"}))
+			Eventually(reconciler.Sharder.Rebalanced).Should(Receive())
+
+			By("Reconciling every ChangeTriggeredJob")
+			result, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(config.DefaultControllerConfig.PollInterval))
+		})
+
+		It("Should only write status when it changed, keeping fields set by other writers", func() {
+			By("Creating a ChangeTriggeredJob and a ConfigMap")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{
+							APIVersion: "v1",
+							Kind:       testKindConfigMap,
+							Name:       cmName,
+							Namespace:  ctjNamespace,
+							Fields:     []string{testDataConfig},
+						},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					Cooldown:  &metav1.Duration{Duration: time.Second},
+					History:   ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.ResourceHashes).To(HaveLen(1))
+			resourceVersion := ctj.ResourceVersion
+
+			By("Skipping the write when nothing changed")
+			skipped := testutil.ToFloat64(statusWritesTotal.WithLabelValues("skipped"))
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.ResourceVersion).To(Equal(resourceVersion))
+			Expect(testutil.ToFloat64(statusWritesTotal.WithLabelValues("skipped"))).To(Equal(skipped + 1))
+
+			By("Patching only the changed fields on top of another writer's status")
+			original := ctj.DeepCopy()
+			other := ctj.DeepCopy()
+			meta.SetStatusCondition(&other.Status.Conditions, metav1.Condition{Type: "External", Status: metav1.ConditionTrue, Reason: "Test"})
+			other.Status.TriggerHistory = []metav1.Time{metav1.Now()}
+			Expect(k8sClient.Status().Update(ctx, other)).To(Succeed())
+
+			changed := original.DeepCopy()
+			changed.Status.ResourceHashes[0].Fields[0].LastHash = "changed"
+			Expect(reconciler.patchStatus(ctx, original, changed)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.ResourceHashes[0].Fields[0].LastHash).To(Equal("changed"))
+			Expect(meta.IsStatusConditionTrue(ctj.Status.Conditions, "External")).To(BeTrue())
+			Expect(ctj.Status.TriggerHistory).To(HaveLen(1))
+		})
+
+		It("Should record changes without triggering while suspended, and run a job on request", func() {
+			By("Creating a suspended ChangeTriggeredJob and a ConfigMap")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{
+							APIVersion: "v1",
+							Kind:       testKindConfigMap,
+							Name:       cmName,
+							Namespace:  ctjNamespace,
+							Fields:     []string{testDataConfig},
+						},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					Cooldown:  &metav1.Duration{Duration: time.Hour},
+					History:   ptr.To(int32(5)),
+					Suspend:   ptr.To(true),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+			countJobs := func() int {
+				jobList := &batchv1.JobList{}
+				Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+				return len(jobList.Items)
+			}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing the ConfigMap while suspended")
+			cm.Data[testFieldConfig] = testValue2
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(countJobs()).To(Equal(0))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(meta.FindStatusCondition(ctj.Status.Conditions, triggersv1alpha.ConditionTypeDegraded).Message).To(HavePrefix("Suspended"))
+
+			By("Resuming does not trigger on the change made while suspended")
+			ctj.Spec.Suspend = nil
+			Expect(k8sClient.Update(ctx, ctj)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(countJobs()).To(Equal(0))
+
+			By("Requesting a trigger runs a job once")
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			ctj.Annotations = map[string]string{triggersv1alpha.TriggerRequestedAtAnnotation: "2025-06-01T12:00:00Z"}
+			Expect(k8sClient.Update(ctx, ctj)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(countJobs()).To(Equal(1))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.LastHandledTriggerRequest).To(Equal("2025-06-01T12:00:00Z"))
+
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(countJobs()).To(Equal(1))
+		})
+
+		It("Should accumulate changes across polls for TriggerConditionAll until they expire", func() {
+			cmName2 := fmt.Sprintf("test-cm2-%d", time.Now().UnixNano())
+
+			By("Creating a ChangeTriggeredJob with TriggerConditionAll and a change window")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{APIVersion: "v1", Kind: testKindConfigMap, Name: cmName, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+						{APIVersion: "v1", Kind: testKindConfigMap, Name: cmName2, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+					},
+					Condition:    ptr.To(triggersv1alpha.TriggerConditionAll),
+					ChangeWindow: &metav1.Duration{Duration: 10 * time.Minute},
+					Cooldown:     &metav1.Duration{Duration: 0},
+					History:      ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm1 := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm1)).Should(Succeed())
+			cm2 := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName2, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm2)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+			countJobs := func() int {
+				jobList := &batchv1.JobList{}
+				Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+				return len(jobList.Items)
+			}
+			change := func(cm *corev1.ConfigMap, value string) {
+				cm.Data[testFieldConfig] = value
+				Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+				_, err := reconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing the ConfigMaps in different polls")
+			change(cm1, testValue2)
+			Expect(countJobs()).To(Equal(0))
+			Expect(ctj.Status.ResourceHashes[0].ChangedAt).NotTo(BeNil())
+			Expect(ctj.Status.ResourceHashes[1].ChangedAt).To(BeNil())
+
+			change(cm2, testValue2)
+			Expect(countJobs()).To(Equal(1))
+			Expect(ctj.Status.ResourceHashes[0].ChangedAt).To(BeNil())
+			Expect(ctj.Status.ResourceHashes[1].ChangedAt).To(BeNil())
+
+			By("Letting an accumulated change expire")
+			change(cm1, testValue1)
+			ctj.Status.ResourceHashes[0].ChangedAt = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+			Expect(k8sClient.Status().Update(ctx, ctj)).To(Succeed())
+
+			change(cm2, testValue1)
+			Expect(countJobs()).To(Equal(1))
+			Expect(ctj.Status.ResourceHashes[0].ChangedAt).To(BeNil())
+			Expect(ctj.Status.ResourceHashes[1].ChangedAt).NotTo(BeNil())
+		})
+
+		It("Should not carry a change to a negated identifier over to the next poll", func() {
+			cmName2 := fmt.Sprintf("test-cm2-%d", time.Now().UnixNano())
+
+			By("Creating a ChangeTriggeredJob with a negated trigger expression")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{ID: "a", APIVersion: "v1", Kind: testKindConfigMap, Name: cmName, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+						{ID: "b", APIVersion: "v1", Kind: testKindConfigMap, Name: cmName2, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+					},
+					Condition:  ptr.To(triggersv1alpha.TriggerConditionExpression),
+					Expression: "!a && b",
+					Cooldown:   &metav1.Duration{Duration: 0},
+					History:    ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm1 := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm1)).Should(Succeed())
+			cm2 := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName2, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm2)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+			countJobs := func() int {
+				jobList := &batchv1.JobList{}
+				Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+				return len(jobList.Items)
+			}
+			change := func(cms ...*corev1.ConfigMap) {
+				for _, cm := range cms {
+					cm.Data[testFieldConfig] += "-changed"
+					Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+				}
+				_, err := reconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing a in one poll and b in the next")
+			change(cm1)
+			Expect(countJobs()).To(Equal(0))
+			Expect(ctj.Status.ResourceHashes[0].ChangedAt).To(BeNil())
+
+			change(cm2)
+			Expect(countJobs()).To(Equal(1))
+
+			By("Not triggering when a and b change in the same poll")
+			change(cm1, cm2)
+			Expect(countJobs()).To(Equal(1))
+		})
+
+		It("Should trigger on a trigger expression and record the clause that fired", func() {
+			cmName2 := fmt.Sprintf("test-cm2-%d", time.Now().UnixNano())
+
+			By("Creating a ChangeTriggeredJob with a trigger expression")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{ID: "dbConfig", APIVersion: "v1", Kind: testKindConfigMap, Name: cmName, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+						{ID: "featureFlags", APIVersion: "v1", Kind: testKindConfigMap, Name: cmName2, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+					},
+					Condition:  ptr.To(triggersv1alpha.TriggerConditionExpression),
+					Expression: "dbConfig && featureFlags || unknown",
+					Cooldown:   &metav1.Duration{Duration: 0},
+					History:    ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm1 := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm1)).Should(Succeed())
+			cm2 := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName2, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm2)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Marking the ChangeTriggeredJob Degraded while the expression refers to an unknown ID")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			degraded := meta.FindStatusCondition(ctj.Status.Conditions, triggersv1alpha.ConditionTypeDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Reason).To(Equal(triggersv1alpha.ReasonInvalidTriggerExpression))
+
+			By("Fixing the expression and establishing the baseline")
+			ctj.Spec.Expression = "dbConfig && featureFlags || !dbConfig && featureFlags"
+			Expect(k8sClient.Update(ctx, ctj)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing only featureFlags")
+			cm2.Data[testFieldConfig] = testValue2
+			Expect(k8sClient.Update(ctx, cm2)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.LastTriggerReason).To(Equal(`Expression clause "!dbConfig && featureFlags" fired`))
+		})
+
+		It("Should require every watched field to change and render context fields into the job", func() {
+			By("Creating a ChangeTriggeredJob with the All field condition and a context field")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{
+							APIVersion:     "v1",
+							Kind:           testKindConfigMap,
+							Name:           cmName,
+							Namespace:      ctjNamespace,
+							Fields:         []string{testDataConfig, "data.image"},
+							FieldCondition: ptr.To(triggersv1alpha.FieldConditionAll),
+							ContextFields:  []triggersv1alpha.ContextField{{Field: "data.version", Env: "APP_VERSION"}},
+						},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					Cooldown:  &metav1.Duration{Duration: 0},
+					History:   ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers: []corev1.Container{{
+										Name:  testContainerName,
+										Image: testImageBusybox,
+										Env:   []corev1.EnvVar{{Name: "APP_VERSION", Value: "template"}},
+									}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1, "image": testValue1, "version": "1.0.0"},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing only one watched field and the context field")
+			cm.Data[testFieldConfig] = testValue2
+			cm.Data["version"] = "1.1.0"
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(BeEmpty())
+
+			By("Changing both watched fields")
+			cm.Data[testFieldConfig] = testValue1
+			cm.Data["image"] = testValue2
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+			Expect(jobList.Items[0].Spec.Template.Spec.Containers[0].Env).To(Equal([]corev1.EnvVar{{Name: "APP_VERSION", Value: "1.1.0"}}))
+		})
+
+		It("Should hold jobs outside the trigger windows until one opens", func() {
+			now := time.Now().UTC()
+			closed := triggersv1alpha.TriggerWindow{Start: now.Add(2 * time.Hour).Format("15:04"), End: now.Add(3 * time.Hour).Format("15:04")}
+
+			By("Creating a ChangeTriggeredJob with a window that opens in two hours")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{APIVersion: "v1", Kind: testKindConfigMap, Name: cmName, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+					},
+					Condition:      ptr.To(triggersv1alpha.TriggerConditionAny),
+					TriggerWindows: []triggersv1alpha.TriggerWindow{closed},
+					Cooldown:       &metav1.Duration{Duration: 0},
+					History:        ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Holding the change until the window opens")
+			cm.Data[testFieldConfig] = testValue2
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(BeEmpty())
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.PendingTriggerReason).To(ContainSubstring("Condition Any met by"))
+			Expect(ctj.Status.NextEligibleTime).NotTo(BeNil())
+			Expect(ctj.Status.NextEligibleTime.Time).To(BeTemporally("~", now.Add(2*time.Hour), time.Minute))
+
+			By("Triggering the pending job without further changes once the window is open")
+			ctj.Spec.TriggerWindows = []triggersv1alpha.TriggerWindow{{Start: now.Add(-time.Hour).Format("15:04"), End: closed.End}}
+			Expect(k8sClient.Update(ctx, ctj)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.PendingTriggerReason).To(BeEmpty())
+			Expect(ctj.Status.NextEligibleTime).To(BeNil())
+			Expect(ctj.Status.LastTriggerReason).To(ContainSubstring("Condition Any met by"))
+		})
+
+		It("Should run scheduled jobs when nothing changed and annotate what triggered each job", func() {
+			By("Creating a ChangeTriggeredJob that runs every year")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{APIVersion: "v1", Kind: testKindConfigMap, Name: cmName, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					Schedule:  "0 0 1 1 *",
+					Cooldown:  &metav1.Duration{Duration: 0},
+					History:   ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			By("Recording a scheduled run that was handled last year")
+			thisYear := time.Date(time.Now().UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+			ctj.Status.LastScheduleTime = &metav1.Time{Time: thisYear.AddDate(-1, 0, 0)}
+			Expect(k8sClient.Status().Update(ctx, ctj)).To(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Running the missed run without changes")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+			Expect(jobList.Items[0].Annotations).To(HaveKeyWithValue(triggersv1alpha.TriggeredByAnnotation, string(triggersv1alpha.TriggeredByScheduled)))
+			Expect(jobList.Items[0].Annotations[triggersv1alpha.TriggerReasonAnnotation]).To(HavePrefix("Scheduled run at "))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.LastTriggeredBy).To(Equal(triggersv1alpha.TriggeredByScheduled))
+			Expect(ctj.Status.LastScheduleTime.Time).To(BeTemporally("==", thisYear))
+			Expect(ctj.Status.NextScheduleTime.Time).To(BeTemporally("==", thisYear.AddDate(1, 0, 0)))
+
+			By("Not running the same scheduled run twice")
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+
+			By("Annotating jobs triggered by changes")
+			cm.Data[testFieldConfig] = testValue2
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(2))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.LastTriggeredBy).To(Equal(triggersv1alpha.TriggeredByChanged))
+			for _, job := range jobList.Items {
+				if job.Name == ctj.Status.LastJobName {
+					Expect(job.Annotations).To(HaveKeyWithValue(triggersv1alpha.TriggeredByAnnotation, string(triggersv1alpha.TriggeredByChanged)))
+					Expect(job.Annotations).To(HaveKeyWithValue(triggersv1alpha.TriggerReasonAnnotation, ctj.Status.LastTriggerReason))
+				}
+			}
+		})
+
+		It("Should queue jobs while the rate limit budget is exhausted", func() {
+			By("Creating a ChangeTriggeredJob limited to one job per hour")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{APIVersion: "v1", Kind: testKindConfigMap, Name: cmName, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+					},
+					Condition: ptr.To(triggersv1alpha.TriggerConditionAny),
+					RateLimit: &triggersv1alpha.TriggerRateLimit{MaxTriggers: 1, Window: metav1.Duration{Duration: time.Hour}, Policy: triggersv1alpha.RateLimitPolicyQueue},
+					Cooldown:  &metav1.Duration{Duration: 0},
+					History:   ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Config: config.DefaultControllerConfig,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Triggering the first job and exhausting the budget")
+			cm.Data[testFieldConfig] = testValue2
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.TriggerHistory).To(HaveLen(1))
+			Expect(meta.IsStatusConditionTrue(ctj.Status.Conditions, triggersv1alpha.ConditionTypeRateLimited)).To(BeTrue())
+
+			By("Queuing the next change")
+			cm.Data[testFieldConfig] = testValue1
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.PendingTriggerReason).To(ContainSubstring("Condition Any met by"))
+			Expect(ctj.Status.NextEligibleTime.Time).To(BeTemporally("==", ctj.Status.TriggerHistory[0].Add(time.Hour)))
+
+			By("Triggering the queued job once the first one left the window")
+			ctj.Status.TriggerHistory = []metav1.Time{{Time: time.Now().Add(-2 * time.Hour)}}
+			Expect(k8sClient.Status().Update(ctx, ctj)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(2))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.PendingTriggerReason).To(BeEmpty())
+			Expect(ctj.Status.TriggerHistory).To(HaveLen(1))
+		})
+
+		It("Should suspend triggering when a watched field flaps until acknowledged", func() {
+			By("Creating a ChangeTriggeredJob suspending after 3 changes in an hour")
+			ctj := &triggersv1alpha.ChangeTriggeredJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ctjName,
+					Namespace: ctjNamespace,
+				},
+				Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+					Resources: []triggersv1alpha.ResourceReference{
+						{APIVersion: "v1", Kind: testKindConfigMap, Name: cmName, Namespace: ctjNamespace, Fields: []string{testDataConfig}},
+					},
+					Condition:         ptr.To(triggersv1alpha.TriggerConditionAny),
+					FlappingDetection: &triggersv1alpha.FlappingDetection{Threshold: 3, Window: metav1.Duration{Duration: time.Hour}, AutoSuspend: true},
+					Cooldown:          &metav1.Duration{Duration: 0},
+					History:           ptr.To(int32(5)),
+					JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									RestartPolicy: corev1.RestartPolicyNever,
+									Containers:    []corev1.Container{{Name: testContainerName, Image: testImageBusybox}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, ctj)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: ctjNamespace},
+				Data:       map[string]string{testFieldConfig: "0"},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			recorder := events.NewFakeRecorder(10)
+			reconciler := &ChangeTriggeredJobReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Config:   config.DefaultControllerConfig,
+				Recorder: recorder,
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: ctjName, Namespace: ctjNamespace}}
+
+			By("Establishing the baseline")
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing the watched field on every poll")
+			for _, value := range []string{"1", "2", "3"} {
+				cm.Data[testFieldConfig] = value
+				Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+				_, err = reconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			jobList := &batchv1.JobList{}
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(2))
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.AutoSuspended).To(BeTrue())
+			flapping := meta.FindStatusCondition(ctj.Status.Conditions, triggersv1alpha.ConditionTypeFlapping)
+			Expect(flapping).NotTo(BeNil())
+			Expect(flapping.Reason).To(Equal(triggersv1alpha.ReasonAutoSuspended))
+			Expect(flapping.Message).To(ContainSubstring("field data.config changed 3 times"))
+			Expect(recorder.Events).To(Receive(ContainSubstring("Warning AutoSuspended")))
+
+			By("Resuming once acknowledged")
+			ctj.Annotations = map[string]string{triggersv1alpha.FlappingAcknowledgedAtAnnotation: time.Now().UTC().Format(time.RFC3339)}
+			Expect(k8sClient.Update(ctx, ctj)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, ctj)).To(Succeed())
+			Expect(ctj.Status.AutoSuspended).To(BeFalse())
+			Expect(meta.IsStatusConditionFalse(ctj.Status.Conditions, triggersv1alpha.ConditionTypeFlapping)).To(BeTrue())
+
+			cm.Data[testFieldConfig] = "4"
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.List(ctx, jobList, client.InNamespace(ctjNamespace), client.MatchingLabels{DefaultLabel: ctjName})).To(Succeed())
+			Expect(jobList.Items).To(HaveLen(3))
+		})
 	})
 })
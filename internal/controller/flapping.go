@@ -0,0 +1,150 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+// FlappingField is a watched field that changed at least the flapping threshold within the window
+type FlappingField struct {
+	Resource string
+	Field    string
+	Changes  int
+}
+
+func (f FlappingField) String() string {
+	return fmt.Sprintf("%s field %s", f.Resource, f.Field)
+}
+
+// RecordFieldChanges carries the recent change times of every watched field from the last poll to the current one,
+// dropping the ones outside the flapping detection window and adding now for the fields that changed. Without
+// flapping detection no change times are kept.
+func RecordFieldChanges(detection *triggersv1alpha.FlappingDetection, last, current []triggersv1alpha.ResourceReferenceStatus, now time.Time) {
+	lastStatuses := statusesByKey(last)
+	for i := range current {
+		after := &current[i]
+		before, ok := lastStatuses[statusKey(*after)]
+		for j := range after.Fields {
+			field := &after.Fields[j]
+			field.RecentChanges = nil
+			if detection == nil || !ok || before.KeyID != after.KeyID {
+				continue
+			}
+			start := now.Add(-detection.Window.Duration)
+			for _, previous := range before.Fields {
+				if previous.Field != field.Field {
+					continue
+				}
+				for _, t := range previous.RecentChanges {
+					if t.After(start) {
+						field.RecentChanges = append(field.RecentChanges, t)
+					}
+				}
+				if previous.LastHash != field.LastHash {
+					field.RecentChanges = append(field.RecentChanges, metav1.Time{Time: now})
+				}
+			}
+		}
+	}
+}
+
+// FlappingFields returns the watched fields that changed at least the flapping threshold within the window
+func FlappingFields(detection *triggersv1alpha.FlappingDetection, statuses []triggersv1alpha.ResourceReferenceStatus) []FlappingField {
+	if detection == nil {
+		return nil
+	}
+	var flapping []FlappingField
+	for _, status := range statuses {
+		for _, field := range status.Fields {
+			if len(field.RecentChanges) >= int(detection.Threshold) {
+				flapping = append(flapping, FlappingField{Resource: statusKey(status), Field: field.Field, Changes: len(field.RecentChanges)})
+			}
+		}
+	}
+	return flapping
+}
+
+// clearFieldChanges forgets the recent changes of every watched field, e.g., once flapping is acknowledged
+func clearFieldChanges(statuses []triggersv1alpha.ResourceReferenceStatus) {
+	for i := range statuses {
+		for j := range statuses[i].Fields {
+			statuses[i].Fields[j].RecentChanges = nil
+		}
+	}
+}
+
+// describeFlapping names the flapping fields and how often they changed
+func describeFlapping(flapping []FlappingField, window time.Duration) string {
+	descriptions := make([]string, 0, len(flapping))
+	for _, field := range flapping {
+		descriptions = append(descriptions, fmt.Sprintf("%s changed %d times in the last %s", field, field.Changes, window))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// Record the flapping fields as the Flapping condition, removed without flapping detection, and suspend triggering
+// when configured. An Event names the fields when flapping starts or suspends triggering.
+func (r *ChangeTriggeredJobReconciler) setFlapping(changeJob *triggersv1alpha.ChangeTriggeredJob, flapping []FlappingField) {
+	detection := changeJob.Spec.FlappingDetection
+	if detection == nil {
+		changeJob.Status.AutoSuspended = false
+		meta.RemoveStatusCondition(&changeJob.Status.Conditions, triggersv1alpha.ConditionTypeFlapping)
+		return
+	}
+
+	window := detection.Window.Duration
+	condition := metav1.Condition{
+		Type:               triggersv1alpha.ConditionTypeFlapping,
+		Status:             metav1.ConditionFalse,
+		Reason:             triggersv1alpha.ReasonStable,
+		Message:            fmt.Sprintf("No watched field changed %d or more times in the last %s", detection.Threshold, window),
+		ObservedGeneration: changeJob.Generation,
+	}
+	if len(flapping) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = triggersv1alpha.ReasonFieldFlapping
+		condition.Message = describeFlapping(flapping, window)
+		if detection.AutoSuspend && !changeJob.Status.AutoSuspended {
+			log.Info("Watched fields are flapping, suspending triggering", "name", changeJob.Name, "fields", condition.Message)
+			changeJob.Status.AutoSuspended = true
+		}
+	}
+	if changeJob.Status.AutoSuspended {
+		if len(flapping) == 0 {
+			condition.Message = "A watched field flapped"
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = triggersv1alpha.ReasonAutoSuspended
+		condition.Message += fmt.Sprintf(", triggering is suspended until the %s annotation is set to a new value", triggersv1alpha.FlappingAcknowledgedAtAnnotation)
+	}
+
+	previous := meta.FindStatusCondition(changeJob.Status.Conditions, triggersv1alpha.ConditionTypeFlapping)
+	started := previous == nil || previous.Status != metav1.ConditionTrue || previous.Reason != condition.Reason
+	if condition.Status == metav1.ConditionTrue && started && r.Recorder != nil {
+		r.Recorder.Eventf(changeJob, nil, corev1.EventTypeWarning, condition.Reason, "Poll", "%s", condition.Message)
+	}
+	meta.SetStatusCondition(&changeJob.Status.Conditions, condition)
+}
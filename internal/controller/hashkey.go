@@ -0,0 +1,98 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// HashKeySecretKey is the data key holding the HMAC key in the hash key Secret
+	HashKeySecretKey = "key"
+	// MinHashKeyLength is the minimum HMAC key length in bytes
+	MinHashKeyLength = 32
+	// DefaultHashKeyTTL is how long a loaded key is used before the Secret is read again
+	DefaultHashKeyTTL = time.Minute
+)
+
+// HashKey is an HMAC key for field hashes, ID identifies it in status without revealing it
+type HashKey struct {
+	ID    string
+	Value []byte
+}
+
+// NewHashKey derives the key ID from the key itself, so rotating the Secret value changes it
+func NewHashKey(value []byte) *HashKey {
+	mac := hmac.New(sha256.New, value)
+	mac.Write([]byte("kube-changejob key id"))
+	return &HashKey{
+		ID:    hex.EncodeToString(mac.Sum(nil))[:16],
+		Value: value,
+	}
+}
+
+// HashKeyProvider loads the HMAC key from a Secret and caches it for TTL
+type HashKeyProvider struct {
+	Reader client.Reader
+	Secret types.NamespacedName
+	TTL    time.Duration
+
+	mu      sync.Mutex
+	key     *HashKey
+	expires time.Time
+}
+
+// Key returns the current HMAC key, reading the Secret when the cached key has expired
+func (p *HashKeyProvider) Key(ctx context.Context) (*HashKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.key != nil && time.Now().Before(p.expires) {
+		return p.key, nil
+	}
+
+	var secret corev1.Secret
+	if err := p.Reader.Get(ctx, p.Secret, &secret); err != nil {
+		return nil, fmt.Errorf("unable to load hash key from Secret %s: %w", p.Secret, err)
+	}
+	value := secret.Data[HashKeySecretKey]
+	if len(value) < MinHashKeyLength {
+		return nil, fmt.Errorf("hash key in Secret %s must be at least %d bytes", p.Secret, MinHashKeyLength)
+	}
+
+	ttl := p.TTL
+	if ttl <= 0 {
+		ttl = DefaultHashKeyTTL
+	}
+	key := NewHashKey(value)
+	if p.key != nil && p.key.ID != key.ID {
+		log.Info("Hash key rotated", "secret", p.Secret, "keyID", key.ID)
+	}
+	p.key = key
+	p.expires = time.Now().Add(ttl)
+	return p.key, nil
+}
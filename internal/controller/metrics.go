@@ -0,0 +1,59 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	pollThrottledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "changejob_poll_throttled_total",
+		Help: "Number of resource polls delayed by the per-kind rate limit",
+	}, []string{"group", "version", "kind"})
+
+	pollThrottleSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "changejob_poll_throttle_seconds",
+		Help:    "Time resource polls waited for the per-kind rate limit",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	}, []string{"group", "version", "kind"})
+
+	statusWritesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "changejob_status_writes_total",
+		Help: "Number of ChangeTriggeredJob status writes by result: patched, skipped when unchanged, or conflict",
+	}, []string{"result"})
+
+	resourceHashesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "changejob_resource_hashes_total",
+		Help: "Number of polled resources by result: hashed, or reused when the resourceVersion is unchanged",
+	}, []string{"result"})
+
+	pollReadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "changejob_poll_reads_total",
+		Help: "Number of watched resource reads by source: cache, metadata or full",
+	}, []string{"source"})
+
+	eventDeliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "changejob_event_deliveries_total",
+		Help: "Number of CloudEvents by result: delivered, failed after retries, dropped when the queue is full, or forbidden by the sink host allowlist",
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(pollThrottledTotal, pollThrottleSeconds, statusWritesTotal, resourceHashesTotal, pollReadsTotal, eventDeliveriesTotal)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/nusnewob/kube-changejob/internal/config"
+)
+
+// NewQueueRateLimiter returns the workqueue rate limiter, the slower of a per-item exponential backoff and an overall token bucket
+func NewQueueRateLimiter(cfg config.ControllerConfig) workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](cfg.RetryBaseDelay, cfg.RetryMaxDelay),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(cfg.QueueQPS), cfg.QueueBurst)},
+	)
+}
+
+// GVKRateLimiter gives every watched GroupVersionKind its own request budget, so one busy kind cannot starve the others
+type GVKRateLimiter struct {
+	QPS   float32
+	Burst int
+
+	mu       sync.Mutex
+	limiters map[schema.GroupVersionKind]*rate.Limiter
+}
+
+// NewGVKRateLimiter returns a limiter allowing qps requests per second with the given burst for every GroupVersionKind
+func NewGVKRateLimiter(qps float32, burst int) *GVKRateLimiter {
+	return &GVKRateLimiter{QPS: qps, Burst: burst}
+}
+
+// Wait blocks until a request for the GroupVersionKind is allowed, recording the time spent throttled
+func (l *GVKRateLimiter) Wait(ctx context.Context, gvk schema.GroupVersionKind) error {
+	if l == nil || l.QPS <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.limiters == nil {
+		l.limiters = make(map[schema.GroupVersionKind]*rate.Limiter)
+	}
+	limiter, ok := l.limiters[gvk]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(l.QPS), max(l.Burst, 1))
+		l.limiters[gvk] = limiter
+	}
+	l.mu.Unlock()
+
+	if limiter.Allow() {
+		return nil
+	}
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	pollThrottledTotal.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Inc()
+	pollThrottleSeconds.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Observe(time.Since(start).Seconds())
+	return err
+}
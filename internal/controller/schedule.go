@@ -0,0 +1,61 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ParseSchedule parses a cron schedule of five fields or a descriptor such as @daily, with an optional
+// CRON_TZ=<time zone> prefix
+func ParseSchedule(schedule string) (cron.Schedule, error) {
+	return cron.ParseStandard(schedule)
+}
+
+// DueSchedule returns the most recent scheduled time after last that is not after now, zero when none is or when it
+// was missed for longer than the starting deadline, and the next scheduled time after now. Times are evaluated in UTC
+// unless the schedule sets a time zone.
+func DueSchedule(schedule cron.Schedule, last time.Time, deadline *int64, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	earliest := last.UTC()
+	if deadline != nil {
+		// Runs missed for longer than the deadline are skipped
+		if start := now.Add(-time.Duration(*deadline) * time.Second); start.After(earliest) {
+			earliest = start
+		}
+	}
+
+	// Search back from now in doubling steps for the first start that has a run before now, so a long outage without
+	// a starting deadline does not walk every missed run
+	start, span := earliest, now.Sub(earliest)
+	for step := time.Second; step < span/2; step *= 2 {
+		if next := schedule.Next(now.Add(-step)); !next.IsZero() && !next.After(now) {
+			start = now.Add(-step)
+			break
+		}
+	}
+
+	var due time.Time
+	next := schedule.Next(start)
+	for !next.IsZero() && !next.After(now) {
+		due = next
+		next = schedule.Next(next)
+	}
+	return due, next
+}
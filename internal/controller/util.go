@@ -18,35 +18,144 @@ package controller
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"maps"
+	"reflect"
 	"slices"
 	"strings"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/utils/ptr"
 
 	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/cloudevents"
+	"github.com/nusnewob/kube-changejob/internal/config"
+	"github.com/nusnewob/kube-changejob/internal/expression"
 )
 
 // Poller fetches and hashes Kubernetes resources
 type Poller struct {
 	Client client.Client
+	// Optional: HMAC key for field hashes of Secrets, or of every resource when HashAll is set
+	HashKey *HashKey
+	HashAll bool
+	// Optional: per-GroupVersionKind request budget
+	Limiter *GVKRateLimiter
+	// Optional: informer cache the kinds in CachedKinds are read from, in CacheNamespaces or all namespaces when empty
+	Cache           client.Reader
+	CachedKinds     map[schema.GroupVersionKind]bool
+	CacheNamespaces []string
+}
+
+// Get a client acting as the ChangeTriggeredJob's ServiceAccount, or the controller client when none is set
+func (r *ChangeTriggeredJobReconciler) clientFor(changeJob *triggersv1alpha.ChangeTriggeredJob) (client.Client, error) {
+	if changeJob.Spec.ServiceAccountName == "" {
+		return r.Client, nil
+	}
+	if r.RestConfig == nil {
+		return nil, fmt.Errorf("impersonating ServiceAccount %q requires a rest config", changeJob.Spec.ServiceAccountName)
+	}
+
+	username := fmt.Sprintf("system:serviceaccount:%s:%s", changeJob.Namespace, changeJob.Spec.ServiceAccountName)
+	if c, ok := r.impersonatedClients.Load(username); ok {
+		return c.(client.Client), nil
+	}
+
+	cfg := rest.CopyConfig(r.RestConfig)
+	cfg.Impersonate = rest.ImpersonationConfig{
+		UserName: username,
+		Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:" + changeJob.Namespace, "system:authenticated"},
+	}
+	c, err := client.New(cfg, client.Options{Scheme: r.Scheme, Mapper: r.RESTMapper()})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create client for %s: %w", username, err)
+	}
+
+	actual, _ := r.impersonatedClients.LoadOrStore(username, c)
+	return actual.(client.Client), nil
+}
+
+// Record a configuration or permission problem as the Degraded condition
+func (r *ChangeTriggeredJobReconciler) setDegraded(ctx context.Context, original, changeJob *triggersv1alpha.ChangeTriggeredJob, reason string, cause error) error {
+	meta.SetStatusCondition(&changeJob.Status.Conditions, metav1.Condition{
+		Type:               triggersv1alpha.ConditionTypeDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            cause.Error(),
+		ObservedGeneration: changeJob.Generation,
+	})
+	return r.updateStatus(ctx, original, changeJob)
+}
+
+// Record the ChangeJobPolicies skipped for an invalid namespaceSelector as the PoliciesSkipped condition, removed
+// when none were skipped
+func setPoliciesSkipped(changeJob *triggersv1alpha.ChangeTriggeredJob, invalid []error) {
+	if len(invalid) == 0 {
+		meta.RemoveStatusCondition(&changeJob.Status.Conditions, triggersv1alpha.ConditionTypePoliciesSkipped)
+		return
+	}
+	messages := make([]string, 0, len(invalid))
+	for _, err := range invalid {
+		messages = append(messages, err.Error())
+	}
+	meta.SetStatusCondition(&changeJob.Status.Conditions, metav1.Condition{
+		Type:               triggersv1alpha.ConditionTypePoliciesSkipped,
+		Status:             metav1.ConditionTrue,
+		Reason:             triggersv1alpha.ReasonInvalidPolicy,
+		Message:            strings.Join(messages, "; "),
+		ObservedGeneration: changeJob.Generation,
+	})
 }
 
 // Trigger Job
-func (r *ChangeTriggeredJobReconciler) triggerJob(ctx context.Context, changeJob *triggersv1alpha.ChangeTriggeredJob) (*batchv1.Job, error) {
+func (r *ChangeTriggeredJobReconciler) triggerJob(ctx context.Context, c client.Client, changeJob *triggersv1alpha.ChangeTriggeredJob, triggeredBy triggersv1alpha.TriggeredBy, reason string) (*batchv1.Job, error) {
+	job, err := NewJob(changeJob, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	AnnotateTrigger(job, triggeredBy, reason)
+
+	poller := r.newPoller(c)
+	env, err := poller.ContextEnv(ctx, changeJob.Spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+	SetEnv(job, env)
+
+	if err := c.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	log.Info("Job created", "job", job.Name)
+	// The Job exists even if the status write fails, which would drop a deferred event for good
+	r.emitEventNow(changeJob, cloudevents.TypeJobTriggered, job.Name, cloudevents.JobData{Name: job.Name, Namespace: job.Namespace})
+	return job, nil
+}
+
+// NewJob renders the Job the ChangeTriggeredJob triggers, owned by it
+func NewJob(changeJob *triggersv1alpha.ChangeTriggeredJob, scheme *runtime.Scheme) (*batchv1.Job, error) {
 	// Generate unique job name using GenerateName to stay within K8s 63 char label limit
 	// The job controller will add a unique suffix
 	var labels map[string]string
@@ -61,50 +170,201 @@ func (r *ChangeTriggeredJobReconciler) triggerJob(ctx context.Context, changeJob
 	job.ObjectMeta = metav1.ObjectMeta{
 		GenerateName: fmt.Sprintf("%s-", changeJob.Name),
 		Namespace:    changeJob.Namespace,
-		Annotations:  changeJob.Annotations,
+		Annotations:  maps.Clone(changeJob.Annotations),
 		Labels:       labels,
 	}
-	job.Spec = changeJob.Spec.JobTemplate.Spec
+	job.Spec = *changeJob.Spec.JobTemplate.Spec.DeepCopy()
 
-	if err := controllerutil.SetControllerReference(changeJob, job, r.Scheme); err != nil {
+	if err := controllerutil.SetControllerReference(changeJob, job, scheme); err != nil {
 		return nil, err
 	}
+	return job, nil
+}
 
-	if err := r.Create(ctx, job); err != nil {
-		return nil, err
+// AnnotateTrigger records on the job what triggered it and why
+func AnnotateTrigger(job *batchv1.Job, triggeredBy triggersv1alpha.TriggeredBy, reason string) {
+	if job.Annotations == nil {
+		job.Annotations = make(map[string]string, 2)
 	}
+	job.Annotations[triggersv1alpha.TriggeredByAnnotation] = string(triggeredBy)
+	job.Annotations[triggersv1alpha.TriggerReasonAnnotation] = reason
+}
 
-	log.Info("Job created", "job", job.Name)
-	return job, nil
+// SetEnv sets the environment variables in every container and init container of the job, replacing the variables
+// of the same name from the job template
+func SetEnv(job *batchv1.Job, env []corev1.EnvVar) {
+	podSpec := &job.Spec.Template.Spec
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			for _, e := range env {
+				containers[i].Env = slices.DeleteFunc(containers[i].Env, func(v corev1.EnvVar) bool { return v.Name == e.Name })
+				containers[i].Env = append(containers[i].Env, e)
+			}
+		}
+	}
+}
+
+// requeueAfter returns the ChangeTriggeredJob's poll interval with random jitter, so polls of jobs created together spread out,
+// or the time until the next trigger window opens for a pending job or the next scheduled run, when it is sooner
+func (r *ChangeTriggeredJobReconciler) requeueAfter(changeJob *triggersv1alpha.ChangeTriggeredJob) time.Duration {
+	cfg := r.config()
+	var requested time.Duration
+	if changeJob.Spec.PollInterval != nil {
+		requested = changeJob.Spec.PollInterval.Duration
+	}
+	interval := cfg.EffectivePollInterval(requested)
+	if cfg.PollJitter > 0 {
+		interval = wait.Jitter(interval, cfg.PollJitter)
+	}
+	for _, next := range []*metav1.Time{changeJob.Status.NextEligibleTime, changeJob.Status.NextScheduleTime} {
+		if next == nil {
+			continue
+		}
+		if until := time.Until(next.Time); until > 0 && until < interval {
+			interval = until
+		}
+	}
+	return interval
+}
+
+// pendingEvent is a CloudEvent waiting for the status of its reconcile to be written
+type pendingEvent struct {
+	sink  cloudevents.Sink
+	event cloudevents.Event
+}
+
+// Emit a CloudEvent to the configured sink once the reconcile's status is written, delivery failures never fail the
+// reconcile. Events whose status write fails are dropped and emitted again by the retry, which detects the same
+// change or job outcome.
+func (r *ChangeTriggeredJobReconciler) emitEvent(changeJob *triggersv1alpha.ChangeTriggeredJob, eventType string, subject string, data any) {
+	p, ok := r.newEvent(changeJob, eventType, subject, data)
+	if !ok {
+		return
+	}
+	key := client.ObjectKeyFromObject(changeJob)
+	pending, _ := r.pendingEvents.Load(key)
+	events, _ := pending.([]pendingEvent)
+	r.pendingEvents.Store(key, append(events, p))
+}
+
+// Emit a CloudEvent right away, for facts the retry of a failed status write cannot observe again, such as a created
+// Job
+func (r *ChangeTriggeredJobReconciler) emitEventNow(changeJob *triggersv1alpha.ChangeTriggeredJob, eventType string, subject string, data any) {
+	if p, ok := r.newEvent(changeJob, eventType, subject, data); ok {
+		r.enqueueEvent(p)
+	}
+}
+
+// newEvent builds a CloudEvent for the configured sink, false without one. A ChangeTriggeredJob's own sink is only
+// used when its host is in config.ControllerConfig.EventSinkAllowedHosts.
+func (r *ChangeTriggeredJobReconciler) newEvent(changeJob *triggersv1alpha.ChangeTriggeredJob, eventType string, subject string, data any) (pendingEvent, bool) {
+	cfg := r.config()
+	if r.Events == nil || !cfg.Enabled(config.FeatureCloudEvents) {
+		return pendingEvent{}, false
+	}
+
+	var sink cloudevents.Sink
+	switch {
+	case changeJob.Spec.EventSink != nil:
+		if !cloudevents.AllowedHost(changeJob.Spec.EventSink.URL, cfg.EventSinkAllowedHosts) {
+			log.Info("Event sink host is not allowed, dropping event", "url", changeJob.Spec.EventSink.URL, "type", eventType)
+			eventDeliveriesTotal.WithLabelValues("forbidden").Inc()
+			return pendingEvent{}, false
+		}
+		sink = &cloudevents.HTTPSink{URL: changeJob.Spec.EventSink.URL, Mode: string(changeJob.Spec.EventSink.Mode)}
+	case cfg.EventSinkURL != "":
+		sink = &cloudevents.HTTPSink{URL: cfg.EventSinkURL, Mode: cfg.EventSinkMode}
+	default:
+		return pendingEvent{}, false
+	}
+
+	return pendingEvent{sink: sink, event: cloudevents.Event{
+		ID:      string(uuid.NewUUID()),
+		Source:  fmt.Sprintf("/apis/%s/namespaces/%s/changetriggeredjobs/%s", triggersv1alpha.GroupVersionString, changeJob.Namespace, changeJob.Name),
+		Type:    eventType,
+		Subject: subject,
+		Time:    time.Now(),
+		Data:    data,
+	}}, true
+}
+
+// Queue the CloudEvents of the reconcile for delivery, called once its status is written
+func (r *ChangeTriggeredJobReconciler) flushEvents(changeJob *triggersv1alpha.ChangeTriggeredJob) {
+	pending, ok := r.pendingEvents.LoadAndDelete(client.ObjectKeyFromObject(changeJob))
+	if !ok {
+		return
+	}
+	for _, p := range pending.([]pendingEvent) {
+		r.enqueueEvent(p)
+	}
+}
+
+// Queue a CloudEvent for delivery in the background, counting the outcome
+func (r *ChangeTriggeredJobReconciler) enqueueEvent(p pendingEvent) {
+	done := func(err error) {
+		if err != nil {
+			log.Error(err, "unable to deliver event", "type", p.event.Type, "subject", p.event.Subject)
+			eventDeliveriesTotal.WithLabelValues("failed").Inc()
+			return
+		}
+		eventDeliveriesTotal.WithLabelValues("delivered").Inc()
+	}
+	if !r.Events.Enqueue(p.sink, p.event, done) {
+		log.Info("Event queue is full, dropping event", "type", p.event.Type, "subject", p.event.Subject)
+		eventDeliveriesTotal.WithLabelValues("dropped").Inc()
+	}
 }
 
 // Poll fetches the resource, extracts fields, and hashes them
 func (p *Poller) Poll(ctx context.Context, ref triggersv1alpha.ResourceReference) (triggersv1alpha.ResourceReferenceStatus, error) {
-	if _, err := ValidateGVK(ctx, p.Client.RESTMapper(), ref.APIVersion, ref.Kind, ref.Namespace); err != nil {
+	return p.PollSince(ctx, ref, nil)
+}
+
+// PollSince is Poll reusing the hashes of the last poll when the resource's resourceVersion and the hash key are
+// unchanged, last must have been polled with the same watched fields
+func (p *Poller) PollSince(ctx context.Context, ref triggersv1alpha.ResourceReference, last *triggersv1alpha.ResourceReferenceStatus) (triggersv1alpha.ResourceReferenceStatus, error) {
+	gvk, err := ValidateGVK(ctx, p.Client.RESTMapper(), ref.APIVersion, ref.Kind, ref.Namespace)
+	if err != nil {
 		return triggersv1alpha.ResourceReferenceStatus{}, err
 	}
 
-	obj := &unstructured.Unstructured{}
-	obj.SetAPIVersion(ref.APIVersion)
-	obj.SetKind(ref.Kind)
+	obj, err := p.get(ctx, *gvk, ref)
+	if err != nil {
+		return triggersv1alpha.ResourceReferenceStatus{}, err
+	}
+	log.V(1).Info("Resource fetched", "resource", obj)
 
-	key := client.ObjectKey{
-		Name: ref.Name,
+	// Keyed hashes keep low-entropy values such as Secret data from being guessed from status
+	hash := HashObject
+	keyID := ""
+	if p.HashKey != nil && (p.HashAll || (ref.APIVersion == "v1" && ref.Kind == "Secret")) {
+		hashKey := p.HashKey
+		hash = func(obj any) (string, error) { return HMACObject(hashKey.Value, obj) }
+		keyID = hashKey.ID
 	}
-	if ref.Namespace != "" {
-		key.Namespace = ref.Namespace
+
+	status := triggersv1alpha.ResourceReferenceStatus{
+		APIVersion:      ref.APIVersion,
+		Kind:            ref.Kind,
+		Name:            ref.Name,
+		Namespace:       ref.Namespace,
+		KeyID:           keyID,
+		ResourceVersion: obj.GetResourceVersion(),
+		Generation:      obj.GetGeneration(),
 	}
 
-	if err := p.Client.Get(ctx, key, obj); err != nil {
-		return triggersv1alpha.ResourceReferenceStatus{}, err
+	if last != nil && last.ResourceVersion != "" && last.ResourceVersion == status.ResourceVersion && last.KeyID == keyID {
+		resourceHashesTotal.WithLabelValues("reused").Inc()
+		status.Fields = slices.Clone(last.Fields)
+		return status, nil
 	}
-	log.V(1).Info("Resource fetched", "resource", obj)
+	resourceHashesTotal.WithLabelValues("hashed").Inc()
 
 	hashes := make([]triggersv1alpha.ResourceFieldHash, 0, len(ref.Fields))
 
 	for _, field := range ref.Fields {
 		if field == "*" {
-			val, err := HashObject(obj.Object)
+			val, err := hash(obj.Object)
 			if err != nil {
 				return triggersv1alpha.ResourceReferenceStatus{}, err
 			}
@@ -115,177 +375,642 @@ func (p *Poller) Poll(ctx context.Context, ref triggersv1alpha.ResourceReference
 			continue
 		}
 
-		// Use a JSONPath parser to find the field
-		j := jsonpath.New("field")
-		if err := j.Parse(fmt.Sprintf("{.%s}", field)); err != nil {
-			return triggersv1alpha.ResourceReferenceStatus{}, fmt.Errorf("failed to parse jsonpath %q: %w", field, err)
-		}
-		results, err := j.FindResults(obj.Object)
-		// Ignore not found errors
-		if err != nil && !strings.Contains(err.Error(), "is not found") {
-			return triggersv1alpha.ResourceReferenceStatus{}, fmt.Errorf("failed to find results for jsonpath %q: %w", field, err)
-		}
-
-		// Convert results to a list of interfaces for consistent hashing
-		var values []any
-		for _, result := range results {
-			for _, v := range result {
-				values = append(values, v.Interface())
-			}
+		values, err := ExtractField(obj.Object, field)
+		if err != nil {
+			return triggersv1alpha.ResourceReferenceStatus{}, err
 		}
 
 		if len(values) > 0 {
-			hash, err := HashObject(map[string]any{field: values})
+			val, err := hash(map[string]any{field: values})
 			if err != nil {
 				return triggersv1alpha.ResourceReferenceStatus{}, err
 			}
 			hashes = append(hashes, triggersv1alpha.ResourceFieldHash{
 				Field:    field,
-				LastHash: hash,
+				LastHash: val,
 			})
 		}
 	}
 
-	return triggersv1alpha.ResourceReferenceStatus{
-		APIVersion: ref.APIVersion,
-		Kind:       ref.Kind,
-		Name:       ref.Name,
-		Namespace:  ref.Namespace,
-		Fields:     hashes,
-	}, nil
+	status.Fields = hashes
+	return status, nil
 }
 
-// PollResources polls the resources referenced by the given ChangeTriggeredJob.
-func (r *ChangeTriggeredJobReconciler) pollResources(ctx context.Context, changeJob *triggersv1alpha.ChangeTriggeredJob) (bool, []triggersv1alpha.ResourceReferenceStatus, error) {
-	poller := Poller{Client: r.Client}
+// ContextEnv reads the context fields of the resources, as the environment variables they are rendered into
+func (p *Poller) ContextEnv(ctx context.Context, resources []triggersv1alpha.ResourceReference) ([]corev1.EnvVar, error) {
+	var env []corev1.EnvVar
+	for _, ref := range resources {
+		if len(ref.ContextFields) == 0 {
+			continue
+		}
+		gvk, err := ValidateGVK(ctx, p.Client.RESTMapper(), ref.APIVersion, ref.Kind, ref.Namespace)
+		if err != nil {
+			return nil, err
+		}
 
-	updated := make([]triggersv1alpha.ResourceReferenceStatus, 0, len(changeJob.Spec.Resources))
-	resourcesWithChanges := 0
+		// Only the context fields decide whether reading the metadata is enough
+		contextRef := ref
+		contextRef.Fields = make([]string, 0, len(ref.ContextFields))
+		for _, f := range ref.ContextFields {
+			contextRef.Fields = append(contextRef.Fields, f.Field)
+		}
+		obj, err := p.get(ctx, *gvk, contextRef)
+		if err != nil {
+			return nil, err
+		}
 
-	// Build a map of old statuses for efficient lookup
-	oldStatuses := make(map[string]triggersv1alpha.ResourceReferenceStatus)
-	for _, s := range changeJob.Status.ResourceHashes {
-		if s.Namespace != "" {
-			key := fmt.Sprintf("%s/%s/%s/%s", s.APIVersion, s.Kind, s.Namespace, s.Name)
-			oldStatuses[key] = s
-		} else {
-			key := fmt.Sprintf("%s/%s/%s", s.APIVersion, s.Kind, s.Name)
-			oldStatuses[key] = s
+		for _, f := range ref.ContextFields {
+			values, err := ExtractField(obj.Object, f.Field)
+			if err != nil {
+				return nil, err
+			}
+			value, err := contextValue(values)
+			if err != nil {
+				return nil, err
+			}
+			env = append(env, corev1.EnvVar{Name: f.Env, Value: value})
+		}
+	}
+	return env, nil
+}
+
+// contextValue renders a single string value as is and other values as JSON, empty when the field matches nothing
+func contextValue(values []any) (string, error) {
+	var rendered any = values
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+		if s, ok := values[0].(string); ok {
+			return s, nil
 		}
+		rendered = values[0]
 	}
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// get reads the resource from the cache when its kind is cached, only its metadata when only metadata fields are
+// watched, and the full object from the API server otherwise
+func (p *Poller) get(ctx context.Context, gvk schema.GroupVersionKind, ref triggersv1alpha.ResourceReference) (*unstructured.Unstructured, error) {
+	key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+
+	if p.Cache != nil && p.CachedKinds[gvk] && (ref.Namespace == "" || len(p.CacheNamespaces) == 0 || slices.Contains(p.CacheNamespaces, ref.Namespace)) {
+		pollReadsTotal.WithLabelValues("cache").Inc()
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := p.Cache.Get(ctx, key, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+
+	if err := p.Limiter.Wait(ctx, gvk); err != nil {
+		return nil, err
+	}
+
+	if MetadataOnly(ref.Fields) {
+		pollReadsTotal.WithLabelValues("metadata").Inc()
+		partial := &metav1.PartialObjectMetadata{}
+		partial.SetGroupVersionKind(gvk)
+		if err := p.Client.Get(ctx, key, partial); err != nil {
+			return nil, err
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(partial)
+		if err != nil {
+			return nil, err
+		}
+		return &unstructured.Unstructured{Object: content}, nil
+	}
+
+	pollReadsTotal.WithLabelValues("full").Inc()
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := p.Client.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// MetadataOnly reports whether every watched field is under metadata, so the rest of the object need not be read
+func MetadataOnly(fields []string) bool {
+	if len(fields) == 0 {
+		return false
+	}
+	for _, field := range fields {
+		if field != "metadata" && !strings.HasPrefix(field, "metadata.") && !strings.HasPrefix(field, "metadata[") {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseFieldPath parses a watched field expression, e.g. data.key or spec.containers[*].image
+func ParseFieldPath(field string) (*jsonpath.JSONPath, error) {
+	j := jsonpath.New("field")
+	if err := j.Parse(fmt.Sprintf("{.%s}", field)); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// ExtractField returns the values a watched field expression resolves to, empty when nothing matches
+func ExtractField(obj map[string]any, field string) ([]any, error) {
+	// Use a JSONPath parser to find the field
+	j, err := ParseFieldPath(field)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jsonpath %q: %w", field, err)
+	}
+	results, err := j.FindResults(obj)
+	// Ignore not found errors
+	if err != nil && !strings.Contains(err.Error(), "is not found") {
+		return nil, fmt.Errorf("failed to find results for jsonpath %q: %w", field, err)
+	}
+
+	// Convert results to a list of interfaces for consistent hashing
+	var values []any
+	for _, result := range results {
+		for _, v := range result {
+			values = append(values, v.Interface())
+		}
+	}
+
+	return values, nil
+}
+
+// newPoller returns a Poller reading resources with c, from the cache when c is the controller's client
+func (r *ChangeTriggeredJobReconciler) newPoller(c client.Client) Poller {
+	cfg := r.config()
+	poller := Poller{Client: c, HashAll: cfg.HashKeyScope == config.HashKeyScopeAll, Limiter: r.PollRateLimiter}
+	if c == r.Client {
+		// The cache is filled with the controller's permissions, never read it on behalf of a ServiceAccount
+		poller.Cache, poller.CachedKinds, poller.CacheNamespaces = r.Cache, r.cachedKinds, cfg.WatchNamespaces
+	}
+	return poller
+}
+
+// PollResources polls the resources referenced by the given ChangeTriggeredJob, and explains why a job triggers when
+// the trigger condition is met
+func (r *ChangeTriggeredJobReconciler) pollResources(ctx context.Context, c client.Client, changeJob *triggersv1alpha.ChangeTriggeredJob) (string, []triggersv1alpha.ResourceReferenceStatus, error) {
+	poller := r.newPoller(c)
+	if r.HashKeys != nil {
+		key, err := r.HashKeys.Key(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+		poller.HashKey = key
+	}
+
+	updated := make([]triggersv1alpha.ResourceReferenceStatus, 0, len(changeJob.Spec.Resources))
+
+	// Build a map of old statuses for efficient lookup
+	oldStatuses := statusesByKey(changeJob.Status.ResourceHashes)
+
+	// Hashes recorded before a spec change may cover other fields and are never reused
+	reconciled := meta.FindStatusCondition(changeJob.Status.Conditions, triggersv1alpha.ConditionTypeDegraded)
+	reuse := reconciled != nil && reconciled.Reason == triggersv1alpha.ReasonReconciled && reconciled.ObservedGeneration == changeJob.Generation
 
 	for _, ref := range changeJob.Spec.Resources {
-		result, err := poller.Poll(ctx, ref)
+		// Find existing hash for comparison
+		last, ok := oldStatuses[resourceKey(ref)]
+
+		var since *triggersv1alpha.ResourceReferenceStatus
+		if ok && reuse {
+			since = &last
+		}
+		result, err := poller.PollSince(ctx, ref, since)
 		if err != nil {
-			return false, nil, err
+			return "", nil, err
 		}
 
 		// Always add to updated list
 		updated = append(updated, result)
 
-		// Find existing hash for comparison
-		key := ""
-		if ref.Namespace != "" {
-			key = fmt.Sprintf("%s/%s/%s/%s", ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
-		} else {
-			key = fmt.Sprintf("%s/%s/%s", ref.APIVersion, ref.Kind, ref.Name)
+		if ok && last.KeyID != result.KeyID {
+			log.Info("Hash key changed, establishing new baseline", "resource", resourceKey(ref), "keyID", result.KeyID)
 		}
-		last, ok := oldStatuses[key]
-		if !ok {
-			// First time seeing this resource - no comparison needed, just track it
+	}
+
+	changes := DetectChanges(changeJob.Spec.Resources, changeJob.Status.ResourceHashes, updated)
+	RecordFieldChanges(changeJob.Spec.FlappingDetection, changeJob.Status.ResourceHashes, updated, time.Now())
+	for _, change := range changes {
+		ref := change.Resource
+		log.V(1).Info("Resource changed", "APIVersion", ref.APIVersion, "Kind", ref.Kind, "Namespace", ref.Namespace, "Name", ref.Name, "fields", change.Fields)
+		r.emitEvent(changeJob, cloudevents.TypeResourceChanged, resourceKey(ref), cloudevents.ResourceChangedData{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+			Namespace:  ref.Namespace,
+			Fields:     change.Fields,
+		})
+	}
+
+	reason := ""
+	if changeJob.Status.ResourceHashes != nil {
+		if AccumulatesChanges(&changeJob.Spec) {
+			var window time.Duration
+			if changeJob.Spec.ChangeWindow != nil {
+				window = changeJob.Spec.ChangeWindow.Duration
+			}
+			changes = AccumulateChanges(changeJob.Spec.Resources, changeJob.Status.ResourceHashes, updated, changes, window, time.Now())
+		}
+		if len(changes) > 0 {
+			log.V(1).Info(fmt.Sprintf("%d of %d watched resources changed", len(changes), len(changeJob.Spec.Resources)))
+			triggered := ConditionMet(&changeJob.Spec, changes)
+			log.V(1).Info("Trigger condition evaluated", "condition", *changeJob.Spec.Condition, "satisfied", triggered)
+			if triggered {
+				reason = TriggerReason(&changeJob.Spec, changes)
+			}
+		}
+	}
+
+	return reason, updated, nil
+}
+
+// ResourceChange is a watched resource whose field hashes changed since the last poll
+type ResourceChange struct {
+	Resource triggersv1alpha.ResourceReference
+	Fields   []string
+}
+
+// DetectChanges compares the hashes of a poll with the last ones, in the order of resources.
+// Resources without last hashes, or hashed with another key, establish a baseline and are never changed.
+// With the All field condition, a resource only changed when every watched field changed.
+func DetectChanges(resources []triggersv1alpha.ResourceReference, last, current []triggersv1alpha.ResourceReferenceStatus) []ResourceChange {
+	lastStatuses := statusesByKey(last)
+	currentStatuses := statusesByKey(current)
+
+	var changes []ResourceChange
+	for _, ref := range resources {
+		before, ok := lastStatuses[resourceKey(ref)]
+		after, polled := currentStatuses[resourceKey(ref)]
+		if !ok || !polled || before.KeyID != after.KeyID {
+			continue
+		}
+		fields := ChangedFields(before.Fields, after.Fields)
+		if len(fields) == 0 {
 			continue
 		}
+		if ptr.Deref(ref.FieldCondition, triggersv1alpha.FieldConditionAny) == triggersv1alpha.FieldConditionAll && len(fields) < len(hashedFields(before.Fields, after.Fields)) {
+			continue
+		}
+		changes = append(changes, ResourceChange{Resource: ref, Fields: fields})
+	}
+	return changes
+}
 
-		// Compare fields to detect changes
-		resourceHasChanged := false
-		if len(last.Fields) != len(result.Fields) {
-			resourceHasChanged = true
-		} else {
-			// Build maps for efficient field comparison
-			lastFields := make(map[string]string, len(last.Fields))
-			for _, f := range last.Fields {
-				lastFields[f.Field] = f.LastHash
-			}
+// ConditionMet reports whether the changed resources satisfy the trigger condition of spec, and include every
+// required resource
+func ConditionMet(spec *triggersv1alpha.ChangeTriggeredJobSpec, changes []ResourceChange) bool {
+	if len(changes) == 0 {
+		return false
+	}
+	changed := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		changed[resourceKey(change.Resource)] = true
+	}
+	for _, ref := range spec.Resources {
+		if ref.Required && !changed[resourceKey(ref)] {
+			return false
+		}
+	}
 
-			for _, f := range result.Fields {
-				if lastHash, ok := lastFields[f.Field]; !ok || lastHash != f.LastHash {
-					resourceHasChanged = true
-					break
-				}
+	switch ptr.Deref(spec.Condition, triggersv1alpha.TriggerConditionAny) {
+	case triggersv1alpha.TriggerConditionAll:
+		return len(changes) == len(spec.Resources)
+	case triggersv1alpha.TriggerConditionAtLeast:
+		return ChangedWeight(changes) >= ptr.Deref(spec.MinChanged, 1)
+	case triggersv1alpha.TriggerConditionExpression:
+		expr, err := ParseTriggerExpression(spec)
+		return err == nil && expr.Eval(changedIDs(changes))
+	default:
+		return true
+	}
+}
+
+// TriggerReason explains why the changed resources satisfy the trigger condition of spec, naming the expression
+// clause that fired for the Expression condition
+func TriggerReason(spec *triggersv1alpha.ChangeTriggeredJobSpec, changes []ResourceChange) string {
+	condition := ptr.Deref(spec.Condition, triggersv1alpha.TriggerConditionAny)
+	if condition == triggersv1alpha.TriggerConditionExpression {
+		if expr, err := ParseTriggerExpression(spec); err == nil {
+			if clause, ok := expression.FiredClause(expr, changedIDs(changes)); ok {
+				return fmt.Sprintf("Expression clause %q fired", clause)
 			}
 		}
+	}
 
-		if resourceHasChanged {
-			resourcesWithChanges++
-			log.V(1).Info("Resource changed", "APIVersion", ref.APIVersion, "Kind", ref.Kind, "Namespace", ref.Namespace, "Name", ref.Name)
+	changed := make([]string, 0, len(changes))
+	for _, change := range changes {
+		changed = append(changed, resourceKey(change.Resource))
+	}
+	return fmt.Sprintf("Condition %s met by %s", condition, strings.Join(changed, ", "))
+}
+
+// ParseTriggerExpression parses the trigger expression of spec and checks that it only refers to resource IDs
+func ParseTriggerExpression(spec *triggersv1alpha.ChangeTriggeredJobSpec) (expression.Expr, error) {
+	expr, err := expression.Parse(spec.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trigger expression %q: %w", spec.Expression, err)
+	}
+	var unknown []string
+	for _, id := range expression.IDs(expr) {
+		if !slices.ContainsFunc(spec.Resources, func(ref triggersv1alpha.ResourceReference) bool { return ref.ID == id }) {
+			unknown = append(unknown, id)
 		}
 	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("trigger expression %q refers to unknown resource IDs %s", spec.Expression, strings.Join(unknown, ", "))
+	}
+	return expr, nil
+}
 
-	triggered := false
-	if changeJob.Status.ResourceHashes != nil && resourcesWithChanges > 0 {
-		log.V(1).Info(fmt.Sprintf("%d of %d watched resources changed", resourcesWithChanges, len(changeJob.Spec.Resources)))
-		switch *changeJob.Spec.Condition {
-		case triggersv1alpha.TriggerConditionAny:
-			triggered = true
-			log.V(1).Info("Trigger condition 'Any' satisfied")
-		case triggersv1alpha.TriggerConditionAll:
-			if resourcesWithChanges == len(changeJob.Spec.Resources) {
-				triggered = true
-				log.V(1).Info("Trigger condition 'All' satisfied")
-			} else {
-				log.V(1).Info("Trigger condition 'All' not satisfied")
-			}
+// changedIDs reports whether the resource with an ID changed
+func changedIDs(changes []ResourceChange) func(id string) bool {
+	return func(id string) bool {
+		return slices.ContainsFunc(changes, func(change ResourceChange) bool { return change.Resource.ID == id })
+	}
+}
+
+// AccumulatesChanges reports whether changes are accumulated across polls until a job triggers, which only the All
+// condition does. AtLeast and Expression are evaluated against the changes of a single poll, since a change carried
+// over to a negated identifier in an expression would keep it from ever firing.
+func AccumulatesChanges(spec *triggersv1alpha.ChangeTriggeredJobSpec) bool {
+	return ptr.Deref(spec.Condition, triggersv1alpha.TriggerConditionAny) == triggersv1alpha.TriggerConditionAll
+}
+
+// AccumulateChanges records in current when the resources changed in this poll, carries over the changes recorded
+// in last until they are older than window, and returns every change accumulated since the last job in the order of
+// resources. A zero window keeps changes until a job triggers.
+func AccumulateChanges(resources []triggersv1alpha.ResourceReference, last, current []triggersv1alpha.ResourceReferenceStatus, changes []ResourceChange, window time.Duration, now time.Time) []ResourceChange {
+	lastStatuses := statusesByKey(last)
+	changed := make(map[string]ResourceChange, len(changes))
+	for _, change := range changes {
+		changed[resourceKey(change.Resource)] = change
+	}
+	polled := make(map[string]int, len(current))
+	for i, s := range current {
+		polled[resourceKey(triggersv1alpha.ResourceReference{APIVersion: s.APIVersion, Kind: s.Kind, Namespace: s.Namespace, Name: s.Name})] = i
+	}
+
+	var accumulated []ResourceChange
+	for _, ref := range resources {
+		key := resourceKey(ref)
+		i, ok := polled[key]
+		if !ok {
+			continue
+		}
+		if change, ok := changed[key]; ok {
+			current[i].ChangedAt = &metav1.Time{Time: now}
+			accumulated = append(accumulated, change)
+			continue
+		}
+		before, ok := lastStatuses[key]
+		if !ok || before.ChangedAt == nil || (window > 0 && now.Sub(before.ChangedAt.Time) >= window) {
+			current[i].ChangedAt = nil
+			continue
 		}
+		current[i].ChangedAt = before.ChangedAt.DeepCopy()
+		accumulated = append(accumulated, ResourceChange{Resource: ref})
 	}
+	return accumulated
+}
 
-	return triggered, updated, nil
+// clearChanges forgets the changes accumulated since the last job
+func clearChanges(statuses []triggersv1alpha.ResourceReferenceStatus) {
+	for i := range statuses {
+		statuses[i].ChangedAt = nil
+	}
 }
 
-// Update Status
-func (r *ChangeTriggeredJobReconciler) updateStatus(ctx context.Context, changeJob *triggersv1alpha.ChangeTriggeredJob) error {
-	// Use a fresh copy to avoid conflicts
-	latest := &triggersv1alpha.ChangeTriggeredJob{}
-	if err := r.Get(ctx, client.ObjectKey{Namespace: changeJob.Namespace, Name: changeJob.Name}, latest); err != nil {
-		return err
+// ChangedWeight sums the weights of the changed resources
+func ChangedWeight(changes []ResourceChange) int32 {
+	var weight int32
+	for _, change := range changes {
+		weight += ptr.Deref(change.Resource.Weight, 1)
+	}
+	return weight
+}
+
+// ChangedFields returns the watched fields whose hash differs between two polls,
+// including fields that appeared or disappeared
+func ChangedFields(last, current []triggersv1alpha.ResourceFieldHash) []string {
+	lastFields := make(map[string]string, len(last))
+	for _, f := range last {
+		lastFields[f.Field] = f.LastHash
 	}
-	latest.Status = changeJob.Status
 
+	var changed []string
+	for _, f := range current {
+		if lastHash, ok := lastFields[f.Field]; !ok || lastHash != f.LastHash {
+			changed = append(changed, f.Field)
+		}
+		delete(lastFields, f.Field)
+	}
+	for _, f := range last {
+		if _, ok := lastFields[f.Field]; ok {
+			changed = append(changed, f.Field)
+		}
+	}
+
+	return changed
+}
+
+// hashedFields returns the set of fields hashed in either poll
+func hashedFields(last, current []triggersv1alpha.ResourceFieldHash) map[string]bool {
+	fields := make(map[string]bool, len(current))
+	for _, f := range last {
+		fields[f.Field] = true
+	}
+	for _, f := range current {
+		fields[f.Field] = true
+	}
+	return fields
+}
+
+// statusesByKey indexes resource statuses by resourceKey
+func statusesByKey(statuses []triggersv1alpha.ResourceReferenceStatus) map[string]triggersv1alpha.ResourceReferenceStatus {
+	byKey := make(map[string]triggersv1alpha.ResourceReferenceStatus, len(statuses))
+	for _, s := range statuses {
+		byKey[statusKey(s)] = s
+	}
+	return byKey
+}
+
+// Key identifying the watched resource of a status
+func statusKey(status triggersv1alpha.ResourceReferenceStatus) string {
+	return resourceKey(triggersv1alpha.ResourceReference{APIVersion: status.APIVersion, Kind: status.Kind, Namespace: status.Namespace, Name: status.Name})
+}
+
+// Key identifying a watched resource in status
+func resourceKey(ref triggersv1alpha.ResourceReference) string {
+	if ref.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s/%s", ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", ref.APIVersion, ref.Kind, ref.Name)
+}
+
+// Update Status with the latest job info, writing only the fields changed since original was read
+func (r *ChangeTriggeredJobReconciler) updateStatus(ctx context.Context, original, changeJob *triggersv1alpha.ChangeTriggeredJob) error {
 	histories, err := r.listOwnedJobs(ctx, changeJob)
 	if err != nil {
 		return fmt.Errorf("unable to get job histories: %w", err)
 	}
 
 	if len(histories) > 0 {
-		// Use current time if StartTime is not set yet
-		latest.Status.LastJobName = histories[0].Name
+		// Use current time if StartTime is not set yet, keeping it across reconciles of the same job
+		changeJob.Status.LastJobName = histories[0].Name
 		if histories[0].Status.StartTime != nil {
-			latest.Status.LastTriggeredTime = histories[0].Status.StartTime
-		} else {
-			latest.Status.LastTriggeredTime = new(metav1.Now())
+			changeJob.Status.LastTriggeredTime = histories[0].Status.StartTime
+		} else if changeJob.Status.LastJobName != original.Status.LastJobName || changeJob.Status.LastTriggeredTime == nil {
+			changeJob.Status.LastTriggeredTime = new(metav1.Now())
 		}
 
 		// Update LastJobStatus status
-		if histories[0].Status.Failed > 0 {
-			latest.Status.LastJobStatus = triggersv1alpha.JobStateFailed
-		} else if histories[0].Status.Active > 0 {
-			latest.Status.LastJobStatus = triggersv1alpha.JobStateActive
-		} else if histories[0].Status.Succeeded > 0 {
-			latest.Status.LastJobStatus = triggersv1alpha.JobStateSucceeded
+		if state := JobStateOf(&histories[0]); state != "" {
+			changeJob.Status.LastJobStatus = state
+		}
+
+		// Emit job outcome once, when the last job reaches a terminal state
+		if changeJob.Status.LastJobName != original.Status.LastJobName || changeJob.Status.LastJobStatus != original.Status.LastJobStatus {
+			data := cloudevents.JobData{Name: histories[0].Name, Namespace: histories[0].Namespace}
+			switch changeJob.Status.LastJobStatus {
+			case triggersv1alpha.JobStateSucceeded:
+				r.emitEvent(changeJob, cloudevents.TypeJobSucceeded, histories[0].Name, data)
+			case triggersv1alpha.JobStateFailed:
+				r.emitEvent(changeJob, cloudevents.TypeJobFailed, histories[0].Name, data)
+			}
 		}
 	} else {
 		// No jobs running, clear the status
-		latest.Status.LastJobName = ""
-		latest.Status.LastJobStatus = ""
-		latest.Status.LastTriggeredTime = nil
+		changeJob.Status.LastJobName = ""
+		changeJob.Status.LastJobStatus = ""
+		changeJob.Status.LastTriggeredTime = nil
 	}
 
-	if err := r.Status().Update(ctx, latest); err != nil {
+	if err := r.patchStatus(ctx, original, changeJob); err != nil {
 		return fmt.Errorf("unable to update job status: %w", err)
 	}
+	r.flushEvents(changeJob)
 
 	return nil
 }
 
+// patchStatus merge patches the status fields that differ from original, skipping the write when none do.
+// The patch is locked to the resourceVersion it was computed against. On conflict the changes made since original
+// are reapplied on top of the latest status with MergeStatus and the patch is recomputed, so fields, conditions and
+// resource hashes changed by other writers since are kept.
+func (r *ChangeTriggeredJobReconciler) patchStatus(ctx context.Context, original, changeJob *triggersv1alpha.ChangeTriggeredJob) error {
+	if equality.Semantic.DeepEqual(original.Status, changeJob.Status) {
+		statusWritesTotal.WithLabelValues("skipped").Inc()
+		return nil
+	}
+
+	base := original
+	var desired *triggersv1alpha.ChangeTriggeredJob
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		desired = base.DeepCopy()
+		desired.Status = MergeStatus(original.Status, changeJob.Status, base.Status)
+		if equality.Semantic.DeepEqual(base.Status, desired.Status) {
+			return nil
+		}
+		err := r.Status().Patch(ctx, desired, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+		if apierrors.IsConflict(err) {
+			statusWritesTotal.WithLabelValues("conflict").Inc()
+			latest := &triggersv1alpha.ChangeTriggeredJob{}
+			if err := r.Get(ctx, client.ObjectKeyFromObject(original), latest); err != nil {
+				return err
+			}
+			base = latest
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	statusWritesTotal.WithLabelValues("patched").Inc()
+	changeJob.ResourceVersion = desired.ResourceVersion
+	changeJob.Status = desired.Status
+	return nil
+}
+
+// MergeStatus applies the changes made between original and changed on top of latest. Top-level fields are taken
+// from changed only when they differ from original; conditions, resource hashes and trigger history are merged entry
+// by entry so entries added or updated by other writers are kept.
+func MergeStatus(original, changed, latest triggersv1alpha.ChangeTriggeredJobStatus) triggersv1alpha.ChangeTriggeredJobStatus {
+	merged := *latest.DeepCopy()
+	originalValue, changedValue, mergedValue := reflect.ValueOf(original), reflect.ValueOf(changed), reflect.ValueOf(&merged).Elem()
+	for i := range mergedValue.NumField() {
+		if !equality.Semantic.DeepEqual(originalValue.Field(i).Interface(), changedValue.Field(i).Interface()) {
+			mergedValue.Field(i).Set(reflect.ValueOf(changedValue.Field(i).Interface()))
+		}
+	}
+	merged.Conditions = mergeEntries(original.Conditions, changed.Conditions, latest.Conditions, func(condition metav1.Condition) string {
+		return condition.Type
+	})
+	merged.ResourceHashes = mergeEntries(original.ResourceHashes, changed.ResourceHashes, latest.ResourceHashes, statusKey)
+	merged.TriggerHistory = mergeEntries(original.TriggerHistory, changed.TriggerHistory, latest.TriggerHistory, func(t metav1.Time) string {
+		return t.UTC().Format(time.RFC3339Nano)
+	})
+	return *merged.DeepCopy()
+}
+
+// mergeEntries applies the entries added, updated or removed between original and changed to latest, matched by key
+func mergeEntries[T any](original, changed, latest []T, key func(T) string) []T {
+	originals := make(map[string]T, len(original))
+	for _, entry := range original {
+		originals[key(entry)] = entry
+	}
+	changes := make(map[string]T, len(changed))
+	for _, entry := range changed {
+		changes[key(entry)] = entry
+	}
+
+	merged := make([]T, 0, len(latest)+len(changed))
+	seen := make(map[string]bool, len(latest))
+	for _, entry := range latest {
+		k := key(entry)
+		seen[k] = true
+		update, kept := changes[k]
+		prior, existed := originals[k]
+		switch {
+		case existed && !kept:
+			// Removed by this writer
+		case kept && (!existed || !equality.Semantic.DeepEqual(prior, update)):
+			merged = append(merged, update)
+		default:
+			merged = append(merged, entry)
+		}
+	}
+	for _, entry := range changed {
+		k := key(entry)
+		prior, existed := originals[k]
+		if !seen[k] && (!existed || !equality.Semantic.DeepEqual(prior, entry)) {
+			merged = append(merged, entry)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// JobStateOf returns the state of a triggered Job, empty before its pods are created
+func JobStateOf(job *batchv1.Job) triggersv1alpha.JobState {
+	switch {
+	case job.Status.Failed > 0:
+		return triggersv1alpha.JobStateFailed
+	case job.Status.Active > 0:
+		return triggersv1alpha.JobStateActive
+	case job.Status.Succeeded > 0:
+		return triggersv1alpha.JobStateSucceeded
+	}
+	return ""
+}
+
 // Get a list of owned Jobs
 func (r *ChangeTriggeredJobReconciler) listOwnedJobs(ctx context.Context, changeJob *triggersv1alpha.ChangeTriggeredJob) ([]batchv1.Job, error) {
 	var jobs batchv1.JobList
@@ -354,18 +1079,33 @@ func ValidateGVK(ctx context.Context, mapper meta.RESTMapper, apiVersion string,
 
 // hashObject produces a stable hash for arbitrary JSON data
 func HashObject(obj any) (string, error) {
-	data, err := json.Marshal(obj)
+	canonical, err := canonicalJSON(obj)
 	if err != nil {
 		return "", err
 	}
 
-	canonical, err := jsoncanonicalizer.Transform(data)
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HMACObject produces a stable HMAC-SHA256 for arbitrary JSON data
+func HMACObject(key []byte, obj any) (string, error) {
+	canonical, err := canonicalJSON(obj)
 	if err != nil {
 		return "", err
 	}
 
-	sum := sha256.Sum256(canonical)
-	return hex.EncodeToString(sum[:]), nil
+	mac := hmac.New(sha256.New, key)
+	mac.Write(canonical)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func canonicalJSON(obj any) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return jsoncanonicalizer.Transform(data)
 }
 
 // Validates JobTemplate
@@ -19,13 +19,22 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
+	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
 
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
 )
@@ -127,6 +136,95 @@ var _ = Describe("Poller", func() {
 			Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
 		})
 
+		It("Should reuse hashes while the resourceVersion is unchanged", func() {
+			cmName := fmt.Sprintf("test-cm-%d", time.Now().UnixNano())
+
+			By("Creating a ConfigMap")
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cmName,
+					Namespace: namespace,
+				},
+				Data: map[string]string{
+					testMapKey1: testValue1,
+				},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			ref := triggersv1alpha.ResourceReference{
+				APIVersion: "v1",
+				Kind:       testKindConfigMap,
+				Name:       cmName,
+				Namespace:  namespace,
+				Fields:     []string{testDataKey1},
+			}
+
+			By("First poll records the resourceVersion")
+			status1, err := poller.Poll(ctx, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status1.ResourceVersion).To(Equal(cm.ResourceVersion))
+
+			By("Polling the unchanged ConfigMap reuses the hashes")
+			reused := testutil.ToFloat64(resourceHashesTotal.WithLabelValues("reused"))
+			last := status1
+			last.Fields = []triggersv1alpha.ResourceFieldHash{{Field: testDataKey1, LastHash: "recorded"}}
+			status2, err := poller.PollSince(ctx, ref, &last)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status2.Fields).To(Equal(last.Fields))
+			Expect(testutil.ToFloat64(resourceHashesTotal.WithLabelValues("reused"))).To(Equal(reused + 1))
+
+			By("Polling the updated ConfigMap hashes it again")
+			cm.Data[testMapKey1] = testValue2
+			Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			status3, err := poller.PollSince(ctx, ref, &status1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status3.ResourceVersion).To(Equal(cm.ResourceVersion))
+			Expect(status3.Fields[0].LastHash).NotTo(Equal(status1.Fields[0].LastHash))
+
+			Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+		})
+
+		It("Should hash metadata fields the same when only metadata is read", func() {
+			cmName := fmt.Sprintf("test-cm-%d", time.Now().UnixNano())
+
+			By("Creating a labelled ConfigMap")
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cmName,
+					Namespace: namespace,
+					Labels:    map[string]string{"app": cmName},
+				},
+				Data: map[string]string{
+					testMapKey1: testValue1,
+				},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			ref := triggersv1alpha.ResourceReference{
+				APIVersion: "v1",
+				Kind:       testKindConfigMap,
+				Name:       cmName,
+				Namespace:  namespace,
+				Fields:     []string{"metadata.labels"},
+			}
+			Expect(MetadataOnly(ref.Fields)).To(BeTrue())
+
+			By("Polling the metadata only")
+			reads := testutil.ToFloat64(pollReadsTotal.WithLabelValues("metadata"))
+			metadata, err := poller.Poll(ctx, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testutil.ToFloat64(pollReadsTotal.WithLabelValues("metadata"))).To(Equal(reads + 1))
+
+			By("Polling the full object")
+			ref.Fields = []string{"metadata.labels", testDataKey1}
+			full, err := poller.Poll(ctx, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.Fields[0]).To(Equal(full.Fields[0]))
+			Expect(metadata.ResourceVersion).To(Equal(full.ResourceVersion))
+
+			Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+		})
+
 		It("Should handle multiple fields", func() {
 			cmName := fmt.Sprintf("test-cm-%d", time.Now().UnixNano())
 
@@ -319,6 +417,38 @@ var _ = Describe("Poller", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(hash).NotTo(BeEmpty())
 		})
+
+		It("Should report changed, added and removed fields", func() {
+			last := []triggersv1alpha.ResourceFieldHash{
+				{Field: testDataKey1, LastHash: "a"},
+				{Field: testDataConfig, LastHash: "b"},
+				{Field: "data.removed", LastHash: "c"},
+			}
+			current := []triggersv1alpha.ResourceFieldHash{
+				{Field: testDataKey1, LastHash: "a"},
+				{Field: testDataConfig, LastHash: "changed"},
+				{Field: "data.added", LastHash: "d"},
+			}
+
+			Expect(ChangedFields(last, current)).To(Equal([]string{testDataConfig, "data.added", "data.removed"}))
+			Expect(ChangedFields(last, last)).To(BeEmpty())
+		})
+
+		It("Should parse and extract field expressions", func() {
+			_, err := ParseFieldPath("spec.containers[*].image")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = ParseFieldPath("data.key[0")
+			Expect(err).To(HaveOccurred())
+
+			obj := map[string]any{"data": map[string]any{testMapKey1: testValue1}}
+			values, err := ExtractField(obj, testDataKey1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal([]any{testValue1}))
+
+			values, err = ExtractField(obj, "data.missing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(BeEmpty())
+		})
 	})
 
 	Context("ValidateGVK tests", func() {
@@ -658,4 +788,539 @@ var _ = Describe("Poller", func() {
 			Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
 		})
 	})
+
+	Context("Keyed hashing", func() {
+		var (
+			key1 = NewHashKey([]byte("0123456789abcdef0123456789abcdef"))
+			key2 = NewHashKey([]byte("fedcba9876543210fedcba9876543210"))
+		)
+
+		It("Should hash Secrets with the HMAC key and record the key ID", func() {
+			secretName := fmt.Sprintf("test-secret-%d", time.Now().UnixNano())
+			cmName := fmt.Sprintf("test-cm-%d", time.Now().UnixNano())
+
+			By("Creating a Secret and a ConfigMap with the same data")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+				Data:       map[string][]byte{testMapKey1: []byte(testValue1)},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmName, Namespace: namespace},
+				Data:       map[string]string{testMapKey1: testValue1},
+			}
+			Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+
+			secretRef := triggersv1alpha.ResourceReference{
+				APIVersion: "v1",
+				Kind:       "Secret",
+				Name:       secretName,
+				Namespace:  namespace,
+				Fields:     []string{testDataKey1},
+			}
+			cmRef := triggersv1alpha.ResourceReference{
+				APIVersion: "v1",
+				Kind:       testKindConfigMap,
+				Name:       cmName,
+				Namespace:  namespace,
+				Fields:     []string{testDataKey1},
+			}
+
+			By("Polling without a key")
+			plain, err := poller.Poll(ctx, secretRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plain.KeyID).To(BeEmpty())
+
+			By("Polling with a key scoped to Secrets")
+			poller.HashKey = key1
+			keyed, err := poller.Poll(ctx, secretRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keyed.KeyID).To(Equal(key1.ID))
+			Expect(keyed.Fields[0].LastHash).NotTo(Equal(plain.Fields[0].LastHash))
+
+			cmStatus, err := poller.Poll(ctx, cmRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmStatus.KeyID).To(BeEmpty())
+
+			By("Polling with a key scoped to all resources")
+			poller.HashAll = true
+			cmStatus, err = poller.Poll(ctx, cmRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmStatus.KeyID).To(Equal(key1.ID))
+
+			By("Polling with a rotated key")
+			poller.HashKey = key2
+			rotated, err := poller.Poll(ctx, secretRef)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated.KeyID).To(Equal(key2.ID))
+			Expect(rotated.Fields[0].LastHash).NotTo(Equal(keyed.Fields[0].LastHash))
+
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+		})
+
+		It("Should load and cache the key from a Secret", func() {
+			secretName := fmt.Sprintf("test-hash-key-%d", time.Now().UnixNano())
+
+			By("Creating the key Secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+				Data:       map[string][]byte{HashKeySecretKey: key1.Value},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			provider := &HashKeyProvider{
+				Reader: k8sClient,
+				Secret: types.NamespacedName{Name: secretName, Namespace: namespace},
+				TTL:    time.Hour,
+			}
+			key, err := provider.Key(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.ID).To(Equal(key1.ID))
+
+			By("Rotating the key")
+			secret.Data[HashKeySecretKey] = key2.Value
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+
+			key, err = provider.Key(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.ID).To(Equal(key1.ID), "cached key is used until the TTL expires")
+
+			provider.TTL = time.Nanosecond
+			provider.expires = time.Time{}
+			key, err = provider.Key(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.ID).To(Equal(key2.ID))
+
+			By("Rejecting a short key")
+			secret.Data[HashKeySecretKey] = []byte("short")
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+			provider.expires = time.Time{}
+			_, err = provider.Key(ctx)
+			Expect(err).To(HaveOccurred())
+
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("GVKRateLimiter", func() {
+	It("Should throttle every GroupVersionKind separately", func() {
+		ctx := context.Background()
+		limiter := NewGVKRateLimiter(20, 1)
+		configMaps := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+		deployments := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+		throttled := testutil.ToFloat64(pollThrottledTotal.WithLabelValues("", "v1", "ConfigMap"))
+
+		By("Allowing the burst without waiting")
+		Expect(limiter.Wait(ctx, configMaps)).To(Succeed())
+		Expect(limiter.Wait(ctx, deployments)).To(Succeed())
+		Expect(testutil.ToFloat64(pollThrottledTotal.WithLabelValues("", "v1", "ConfigMap"))).To(Equal(throttled))
+
+		By("Waiting once the kind's budget is spent")
+		start := time.Now()
+		Expect(limiter.Wait(ctx, configMaps)).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", 20*time.Millisecond))
+		Expect(testutil.ToFloat64(pollThrottledTotal.WithLabelValues("", "v1", "ConfigMap"))).To(Equal(throttled + 1))
+
+		By("Returning the context error while throttled")
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		Expect(limiter.Wait(cancelled, configMaps)).NotTo(Succeed())
+	})
+
+	It("Should not limit when unset or with zero qps", func() {
+		var limiter *GVKRateLimiter
+		Expect(limiter.Wait(context.Background(), schema.GroupVersionKind{Version: "v1", Kind: "Secret"})).To(Succeed())
+		Expect(NewGVKRateLimiter(0, 1).Wait(context.Background(), schema.GroupVersionKind{Version: "v1", Kind: "Secret"})).To(Succeed())
+	})
+})
+
+// BenchmarkPoll compares reading a large ConfigMap in full, as metadata only and from the informer cache
+func BenchmarkPoll(b *testing.B) {
+	env := &envtest.Environment{BinaryAssetsDirectory: getFirstFoundEnvTestBinaryDir()}
+	restConfig, err := env.Start()
+	if err != nil {
+		b.Fatalf("Failed to start envtest: %v", err)
+	}
+	defer env.Stop() //nolint:errcheck
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		b.Fatal(err)
+	}
+	informers, err := cache.New(restConfig, cache.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		b.Fatal(err)
+	}
+	go informers.Start(ctx) //nolint:errcheck
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bench", Namespace: "default", Labels: map[string]string{"app": "bench"}},
+		Data:       make(map[string]string, 100),
+	}
+	for i := range 100 {
+		cm.Data[fmt.Sprintf("key-%d", i)] = strings.Repeat("x", 1024)
+	}
+	if err := c.Create(ctx, cm); err != nil {
+		b.Fatal(err)
+	}
+
+	gvk := corev1.SchemeGroupVersion.WithKind(testKindConfigMap)
+	benchmarks := []struct {
+		name   string
+		fields []string
+		poller Poller
+	}{
+		{name: "full", fields: []string{"metadata.labels", "data.key-0"}, poller: Poller{Client: c}},
+		{name: "metadata", fields: []string{"metadata.labels"}, poller: Poller{Client: c}},
+		{name: "cache", fields: []string{"metadata.labels", "data.key-0"}, poller: Poller{Client: c, Cache: informers, CachedKinds: map[schema.GroupVersionKind]bool{gvk: true}}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			ref := triggersv1alpha.ResourceReference{APIVersion: "v1", Kind: testKindConfigMap, Name: cm.Name, Namespace: cm.Namespace, Fields: bm.fields}
+			// The first cached read waits for the informer to sync
+			if _, err := bm.poller.Poll(ctx, ref); err != nil {
+				b.Fatal(err)
+			}
+			for b.Loop() {
+				if _, err := bm.poller.Poll(ctx, ref); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+var _ = Describe("Change detection", func() {
+	configMap := func(name string) triggersv1alpha.ResourceReference {
+		return triggersv1alpha.ResourceReference{APIVersion: "v1", Kind: testKindConfigMap, Name: name, Namespace: "default"}
+	}
+	hashes := func(name, hash, keyID string) triggersv1alpha.ResourceReferenceStatus {
+		return triggersv1alpha.ResourceReferenceStatus{
+			APIVersion: "v1", Kind: testKindConfigMap, Name: name, Namespace: "default", KeyID: keyID,
+			Fields: []triggersv1alpha.ResourceFieldHash{{Field: testDataKey1, LastHash: hash}},
+		}
+	}
+	resources := []triggersv1alpha.ResourceReference{configMap("a"), configMap("b"), configMap("c")}
+
+	It("Should report changed resources in spec order, skipping new and re-keyed ones", func() {
+		last := []triggersv1alpha.ResourceReferenceStatus{hashes("b", "1", ""), hashes("a", "1", ""), hashes("c", "1", "")}
+		current := []triggersv1alpha.ResourceReferenceStatus{hashes("a", "2", ""), hashes("b", "2", ""), hashes("c", "2", "key")}
+
+		changes := DetectChanges(resources, last, current)
+		Expect(changes).To(Equal([]ResourceChange{
+			{Resource: configMap("a"), Fields: []string{testDataKey1}},
+			{Resource: configMap("b"), Fields: []string{testDataKey1}},
+		}))
+		Expect(DetectChanges(resources, nil, current)).To(BeEmpty())
+	})
+
+	It("Should evaluate the trigger condition", func() {
+		spec := &triggersv1alpha.ChangeTriggeredJobSpec{Resources: resources}
+		one := []ResourceChange{{Resource: configMap("a")}}
+		all := []ResourceChange{{Resource: configMap("a")}, {Resource: configMap("b")}, {Resource: configMap("c")}}
+
+		Expect(ConditionMet(spec, nil)).To(BeFalse())
+		Expect(ConditionMet(spec, one)).To(BeTrue())
+		spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+		Expect(ConditionMet(spec, one)).To(BeFalse())
+		Expect(ConditionMet(spec, all)).To(BeTrue())
+	})
+
+	It("Should accumulate changes across polls until they expire", func() {
+		now := time.Now()
+		changedAt := func(status triggersv1alpha.ResourceReferenceStatus, at time.Time) triggersv1alpha.ResourceReferenceStatus {
+			status.ChangedAt = &metav1.Time{Time: at}
+			return status
+		}
+		last := []triggersv1alpha.ResourceReferenceStatus{
+			changedAt(hashes("a", "1", ""), now.Add(-time.Minute)),
+			changedAt(hashes("b", "1", ""), now.Add(-time.Hour)),
+			hashes("c", "1", ""),
+		}
+		current := []triggersv1alpha.ResourceReferenceStatus{hashes("c", "2", ""), hashes("b", "1", ""), hashes("a", "1", "")}
+		changes := DetectChanges(resources, last, current)
+
+		accumulated := AccumulateChanges(resources, last, current, changes, 10*time.Minute, now)
+		Expect(accumulated).To(Equal([]ResourceChange{
+			{Resource: configMap("a")},
+			{Resource: configMap("c"), Fields: []string{testDataKey1}},
+		}))
+		Expect(current[0].ChangedAt.Time).To(Equal(now))
+		Expect(current[1].ChangedAt).To(BeNil())
+		Expect(current[2].ChangedAt.Time).To(Equal(now.Add(-time.Minute)))
+
+		By("Keeping changes until a job triggers without a window")
+		Expect(AccumulateChanges(resources, last, current, changes, 0, now)).To(HaveLen(3))
+		Expect(AccumulatesChanges(&triggersv1alpha.ChangeTriggeredJobSpec{})).To(BeFalse())
+		Expect(AccumulatesChanges(&triggersv1alpha.ChangeTriggeredJobSpec{Condition: ptr.To(triggersv1alpha.TriggerConditionAll)})).To(BeTrue())
+		Expect(AccumulatesChanges(&triggersv1alpha.ChangeTriggeredJobSpec{Condition: ptr.To(triggersv1alpha.TriggerConditionAtLeast)})).To(BeFalse())
+		Expect(AccumulatesChanges(&triggersv1alpha.ChangeTriggeredJobSpec{Condition: ptr.To(triggersv1alpha.TriggerConditionExpression)})).To(BeFalse())
+	})
+
+	It("Should evaluate trigger expressions and explain which clause fired", func() {
+		named := func(name, id string) triggersv1alpha.ResourceReference {
+			ref := configMap(name)
+			ref.ID = id
+			return ref
+		}
+		spec := &triggersv1alpha.ChangeTriggeredJobSpec{
+			Resources:  []triggersv1alpha.ResourceReference{named("a", "dbConfig"), named("b", "dbSecret"), named("c", "featureFlags")},
+			Condition:  ptr.To(triggersv1alpha.TriggerConditionExpression),
+			Expression: "(dbConfig && dbSecret) || featureFlags",
+		}
+
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: spec.Resources[0]}})).To(BeFalse())
+		both := []ResourceChange{{Resource: spec.Resources[0]}, {Resource: spec.Resources[1]}}
+		Expect(ConditionMet(spec, both)).To(BeTrue())
+		Expect(TriggerReason(spec, both)).To(Equal(`Expression clause "dbConfig && dbSecret" fired`))
+		Expect(TriggerReason(spec, []ResourceChange{{Resource: spec.Resources[2]}})).To(Equal(`Expression clause "featureFlags" fired`))
+
+		By("Evaluating negation against the changes of a single poll")
+		spec.Expression = "!dbConfig && dbSecret"
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: spec.Resources[1]}})).To(BeTrue())
+		Expect(ConditionMet(spec, both)).To(BeFalse())
+
+		By("Rejecting unknown IDs")
+		spec.Expression = "dbConfig || cache"
+		_, err := ParseTriggerExpression(spec)
+		Expect(err).To(MatchError(ContainSubstring("unknown resource IDs cache")))
+		Expect(ConditionMet(spec, both)).To(BeFalse())
+
+		By("Naming the changed resources for the other conditions")
+		spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAny)
+		Expect(TriggerReason(spec, both)).To(Equal("Condition Any met by v1/ConfigMap/default/a, v1/ConfigMap/default/b"))
+	})
+
+	It("Should evaluate the AtLeast condition with weights and required resources", func() {
+		// a must change, plus any one of b and c
+		required := configMap("a")
+		required.Required = true
+		spec := &triggersv1alpha.ChangeTriggeredJobSpec{
+			Resources:  []triggersv1alpha.ResourceReference{required, configMap("b"), configMap("c")},
+			Condition:  ptr.To(triggersv1alpha.TriggerConditionAtLeast),
+			MinChanged: ptr.To[int32](2),
+		}
+
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: required}})).To(BeFalse())
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: configMap("b")}, {Resource: configMap("c")}})).To(BeFalse())
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: required}, {Resource: configMap("c")}})).To(BeTrue())
+
+		heavy := configMap("b")
+		heavy.Weight = ptr.To[int32](3)
+		spec.Resources[0].Required = false
+		spec.MinChanged = ptr.To[int32](3)
+		Expect(ChangedWeight([]ResourceChange{{Resource: heavy}, {Resource: configMap("c")}})).To(Equal(int32(4)))
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: configMap("a")}, {Resource: configMap("c")}})).To(BeFalse())
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: heavy}})).To(BeTrue())
+
+		spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAny)
+		spec.Resources[2].Required = true
+		Expect(ConditionMet(spec, []ResourceChange{{Resource: heavy}})).To(BeFalse())
+	})
+})
+
+var _ = Describe("Trigger windows", func() {
+	It("Should report whether a window is open and when the next one opens", func() {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		Expect(err).NotTo(HaveOccurred())
+		weeknights := []triggersv1alpha.TriggerWindow{{
+			Days:     []triggersv1alpha.Weekday{"Mon", "Tue", "Wed", "Thu", "Fri"},
+			Start:    "22:00",
+			End:      "02:00",
+			TimeZone: "Europe/Berlin",
+		}}
+
+		By("Staying open past midnight in the window of the day before")
+		open, _, err := NextTriggerWindow(weeknights, time.Date(2025, 6, 7, 1, 0, 0, 0, berlin))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeTrue())
+
+		By("Skipping the weekend to the next window")
+		open, next, err := NextTriggerWindow(weeknights, time.Date(2025, 6, 7, 3, 0, 0, 0, berlin))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeFalse())
+		Expect(next).To(BeTemporally("==", time.Date(2025, 6, 9, 22, 0, 0, 0, berlin)))
+
+		By("Opening at the earliest of several windows")
+		windows := []triggersv1alpha.TriggerWindow{{Start: "09:00", End: "17:00"}, {Days: []triggersv1alpha.Weekday{"Sun"}, Start: "08:30", End: "08:45"}}
+		open, next, err = NextTriggerWindow(windows, time.Date(2025, 6, 8, 8, 0, 0, 0, time.UTC))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeFalse())
+		Expect(next).To(BeTemporally("==", time.Date(2025, 6, 8, 8, 30, 0, 0, time.UTC)))
+
+		By("Always being open without windows")
+		open, _, err = NextTriggerWindow(nil, time.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(open).To(BeTrue())
+
+		By("Rejecting unknown time zones")
+		_, _, err = NextTriggerWindow([]triggersv1alpha.TriggerWindow{{Start: "09:00", End: "17:00", TimeZone: "Mars/Olympus"}}, time.Now())
+		Expect(err).To(MatchError(ContainSubstring(`trigger window 0: unknown time zone "Mars/Olympus"`)))
+	})
+})
+
+var _ = Describe("Schedules", func() {
+	It("Should return the due and the next scheduled run", func() {
+		hourly, err := ParseSchedule("0 * * * *")
+		Expect(err).NotTo(HaveOccurred())
+		last := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+		now := time.Date(2025, 6, 1, 11, 30, 0, 0, time.UTC)
+
+		By("Returning the most recent of several missed runs")
+		due, next := DueSchedule(hourly, last, nil, now)
+		Expect(due).To(BeTemporally("==", time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC)))
+		Expect(next).To(BeTemporally("==", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)))
+
+		By("Returning no run when none is due")
+		due, _ = DueSchedule(hourly, time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC), nil, now)
+		Expect(due.IsZero()).To(BeTrue())
+
+		By("Finding the most recent run after a long outage without walking every missed run")
+		minutely, err := ParseSchedule("* * * * *")
+		Expect(err).NotTo(HaveOccurred())
+		due, next = DueSchedule(minutely, last.AddDate(-10, 0, 0), nil, now)
+		Expect(due).To(BeTemporally("==", now))
+		Expect(next).To(BeTemporally("==", now.Add(time.Minute)))
+
+		By("Skipping runs missed for longer than the starting deadline")
+		due, next = DueSchedule(hourly, last, ptr.To[int64](600), now)
+		Expect(due.IsZero()).To(BeTrue())
+		Expect(next).To(BeTemporally("==", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)))
+		due, _ = DueSchedule(hourly, last, ptr.To[int64](3600), now)
+		Expect(due).To(BeTemporally("==", time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC)))
+
+		By("Evaluating the schedule in its time zone")
+		daily, err := ParseSchedule("CRON_TZ=Europe/Berlin 0 3 * * *")
+		Expect(err).NotTo(HaveOccurred())
+		_, next = DueSchedule(daily, last, nil, now)
+		Expect(next).To(BeTemporally("==", time.Date(2025, 6, 2, 1, 0, 0, 0, time.UTC)))
+
+		By("Rejecting invalid schedules")
+		_, err = ParseSchedule("0 * * *")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Trigger budget", func() {
+	It("Should count the jobs within the rate limit window", func() {
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		limit := &triggersv1alpha.TriggerRateLimit{MaxTriggers: 2, Window: metav1.Duration{Duration: time.Hour}}
+		history := []metav1.Time{
+			{Time: now.Add(-2 * time.Hour)},
+			{Time: now.Add(-50 * time.Minute)},
+			{Time: now.Add(-10 * time.Minute)},
+		}
+
+		By("Dropping the jobs that left the window")
+		history = PruneTriggerHistory(limit, history, now)
+		Expect(history).To(HaveLen(2))
+
+		By("Exhausting the budget until the oldest counted job leaves the window")
+		allowed, next := TriggerBudget(limit, history, now)
+		Expect(allowed).To(BeFalse())
+		Expect(next).To(BeTemporally("==", now.Add(10*time.Minute)))
+
+		By("Allowing jobs once it left")
+		allowed, _ = TriggerBudget(limit, PruneTriggerHistory(limit, history, now.Add(10*time.Minute)), now.Add(10*time.Minute))
+		Expect(allowed).To(BeTrue())
+
+		By("Keeping no history without a rate limit")
+		Expect(PruneTriggerHistory(nil, history, now)).To(BeNil())
+		allowed, _ = TriggerBudget(nil, history, now)
+		Expect(allowed).To(BeTrue())
+	})
+
+	It("Should reject a rate limit without triggers instead of panicking", func() {
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		history := []metav1.Time{{Time: now.Add(-10 * time.Minute)}}
+		Expect(ValidateRateLimit(nil)).To(Succeed())
+		Expect(ValidateRateLimit(&triggersv1alpha.TriggerRateLimit{MaxTriggers: 1, Window: metav1.Duration{Duration: time.Hour}})).To(Succeed())
+
+		for _, maxTriggers := range []int32{0, -1} {
+			limit := &triggersv1alpha.TriggerRateLimit{MaxTriggers: maxTriggers, Window: metav1.Duration{Duration: time.Hour}}
+			Expect(ValidateRateLimit(limit)).To(MatchError(ContainSubstring("rateLimit.maxTriggers must be at least 1")))
+			allowed, _ := TriggerBudget(limit, history, now)
+			Expect(allowed).To(BeTrue())
+		}
+	})
+})
+
+var _ = Describe("Flapping detection", func() {
+	It("Should count the recent changes of every watched field", func() {
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+		detection := &triggersv1alpha.FlappingDetection{Threshold: 3, Window: metav1.Duration{Duration: 10 * time.Minute}}
+		status := func(status, hash string, changes ...time.Duration) triggersv1alpha.ResourceReferenceStatus {
+			var recent []metav1.Time
+			for _, ago := range changes {
+				recent = append(recent, metav1.Time{Time: now.Add(-ago)})
+			}
+			return triggersv1alpha.ResourceReferenceStatus{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "app", Fields: []triggersv1alpha.ResourceFieldHash{
+				{Field: "status.observed", LastHash: status, RecentChanges: recent},
+				{Field: "data.key", LastHash: hash},
+			}}
+		}
+		last := []triggersv1alpha.ResourceReferenceStatus{status("a", "x", 15*time.Minute, 5*time.Minute, 2*time.Minute)}
+
+		By("Dropping changes outside the window and adding the current one")
+		current := []triggersv1alpha.ResourceReferenceStatus{status("b", "x")}
+		RecordFieldChanges(detection, last, current, now)
+		Expect(current[0].Fields[0].RecentChanges).To(HaveLen(3))
+		Expect(current[0].Fields[0].RecentChanges[2].Time).To(BeTemporally("==", now))
+		Expect(current[0].Fields[1].RecentChanges).To(BeEmpty())
+
+		By("Reporting the fields that reached the threshold")
+		Expect(FlappingFields(detection, current)).To(Equal([]FlappingField{{Resource: "v1/ConfigMap/default/app", Field: "status.observed", Changes: 3}}))
+
+		By("Keeping no changes without flapping detection")
+		RecordFieldChanges(nil, last, current, now)
+		Expect(current[0].Fields[0].RecentChanges).To(BeNil())
+		Expect(FlappingFields(nil, last)).To(BeNil())
+	})
+})
+
+var _ = Describe("Status merge", func() {
+	It("Should apply only the changes made since the original status was read", func() {
+		hashes := func(values ...string) []triggersv1alpha.ResourceReferenceStatus {
+			var statuses []triggersv1alpha.ResourceReferenceStatus
+			for _, value := range values {
+				statuses = append(statuses, triggersv1alpha.ResourceReferenceStatus{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: value[:1], Fields: []triggersv1alpha.ResourceFieldHash{{Field: "data", LastHash: value}}})
+			}
+			return statuses
+		}
+		earlier := metav1.NewTime(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+		later := metav1.NewTime(earlier.Add(time.Minute))
+
+		original := triggersv1alpha.ChangeTriggeredJobStatus{
+			LastJobName:    "job-1",
+			ResourceHashes: hashes("a1", "b1"),
+			Conditions:     []metav1.Condition{{Type: triggersv1alpha.ConditionTypeDegraded, Status: metav1.ConditionTrue, Reason: "Before"}},
+			TriggerHistory: []metav1.Time{earlier},
+		}
+		changed := *original.DeepCopy()
+		changed.LastJobName = "job-2"
+		changed.ResourceHashes = hashes("a2", "b1")
+		changed.Conditions = nil
+		changed.TriggerHistory = append(changed.TriggerHistory, later)
+
+		latest := *original.DeepCopy()
+		latest.LastTriggerReason = "other writer"
+		latest.ResourceHashes = hashes("a1", "b2", "c1")
+		latest.Conditions = append(latest.Conditions, metav1.Condition{Type: "External", Status: metav1.ConditionTrue, Reason: "Test"})
+
+		merged := MergeStatus(original, changed, latest)
+		Expect(merged.LastJobName).To(Equal("job-2"))
+		Expect(merged.LastTriggerReason).To(Equal("other writer"))
+		Expect(merged.ResourceHashes).To(Equal(hashes("a2", "b2", "c1")))
+		Expect(merged.Conditions).To(HaveLen(1))
+		Expect(merged.Conditions[0].Type).To(Equal("External"))
+		Expect(merged.TriggerHistory).To(Equal([]metav1.Time{earlier, later}))
+
+		By("Returning the changed status unchanged when nothing was written since")
+		Expect(MergeStatus(original, changed, original)).To(Equal(changed))
+	})
 })
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+// weekdays maps the days of a TriggerWindow to time.Weekday
+var weekdays = map[triggersv1alpha.Weekday]time.Weekday{
+	"Sun": time.Sunday,
+	"Mon": time.Monday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+	"Thu": time.Thursday,
+	"Fri": time.Friday,
+	"Sat": time.Saturday,
+}
+
+// clockPattern matches the HH:MM times of a TriggerWindow
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// triggerWindow is a parsed TriggerWindow
+type triggerWindow struct {
+	days     map[time.Weekday]bool
+	start    time.Time
+	end      time.Time
+	location *time.Location
+}
+
+// parseTriggerWindow checks the days, times and time zone of a trigger window
+func parseTriggerWindow(w triggersv1alpha.TriggerWindow) (triggerWindow, error) {
+	parsed := triggerWindow{days: make(map[time.Weekday]bool, len(w.Days))}
+	for _, day := range w.Days {
+		weekday, ok := weekdays[day]
+		if !ok {
+			return triggerWindow{}, fmt.Errorf("unknown day %q, must be one of Mon, Tue, Wed, Thu, Fri, Sat or Sun", day)
+		}
+		parsed.days[weekday] = true
+	}
+
+	if !clockPattern.MatchString(w.Start) {
+		return triggerWindow{}, fmt.Errorf("start %q must be a time of day HH:MM", w.Start)
+	}
+	if !clockPattern.MatchString(w.End) {
+		return triggerWindow{}, fmt.Errorf("end %q must be a time of day HH:MM", w.End)
+	}
+	parsed.start, _ = time.Parse("15:04", w.Start)
+	parsed.end, _ = time.Parse("15:04", w.End)
+
+	var err error
+	if parsed.location, err = time.LoadLocation(w.TimeZone); err != nil {
+		return triggerWindow{}, fmt.Errorf("unknown time zone %q", w.TimeZone)
+	}
+	return parsed, nil
+}
+
+// ValidateTriggerWindow checks the days, times and time zone of a trigger window
+func ValidateTriggerWindow(w triggersv1alpha.TriggerWindow) error {
+	_, err := parseTriggerWindow(w)
+	return err
+}
+
+// next reports whether t is within the window, and otherwise when it opens next
+func (w triggerWindow) next(t time.Time) (bool, time.Time) {
+	year, month, day := t.In(w.location).Date()
+	// Start the day before, whose window may span midnight
+	for offset := -1; offset <= 7; offset++ {
+		opens := time.Date(year, month, day+offset, w.start.Hour(), w.start.Minute(), 0, 0, w.location)
+		if len(w.days) > 0 && !w.days[opens.Weekday()] {
+			continue
+		}
+		closes := time.Date(year, month, day+offset, w.end.Hour(), w.end.Minute(), 0, 0, w.location)
+		if !closes.After(opens) {
+			closes = time.Date(year, month, day+offset+1, w.end.Hour(), w.end.Minute(), 0, 0, w.location)
+		}
+		if !t.Before(opens) && t.Before(closes) {
+			return true, time.Time{}
+		}
+		if opens.After(t) {
+			return false, opens
+		}
+	}
+	return false, time.Time{}
+}
+
+// NextTriggerWindow reports whether t is within one of the trigger windows, always when there are none, and
+// otherwise when the next one opens
+func NextTriggerWindow(windows []triggersv1alpha.TriggerWindow, t time.Time) (bool, time.Time, error) {
+	if len(windows) == 0 {
+		return true, time.Time{}, nil
+	}
+
+	var next time.Time
+	for i, w := range windows {
+		parsed, err := parseTriggerWindow(w)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("trigger window %d: %w", i, err)
+		}
+		open, opens := parsed.next(t)
+		if open {
+			return true, time.Time{}, nil
+		}
+		if !opens.IsZero() && (next.IsZero() || opens.Before(next)) {
+			next = opens
+		}
+	}
+	return false, next, nil
+}
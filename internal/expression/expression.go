@@ -0,0 +1,286 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package expression parses and evaluates boolean trigger expressions over the IDs of watched resources, e.g.,
+// `(dbConfig && dbSecret) || featureFlags`. An ID is true when its resource changed. Operators are `!`, `&&` and `||`
+// in decreasing precedence, and parentheses group.
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IDPattern matches the resource IDs an expression refers to
+var IDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// MaxDepth is the deepest nesting of parentheses and negations Parse accepts, bounding its recursion
+const MaxDepth = 32
+
+// Expr is a parsed trigger expression
+type Expr interface {
+	// Eval evaluates the expression, changed reports whether the resource with an ID changed
+	Eval(changed func(id string) bool) bool
+	// String renders the expression with the parentheses its precedence requires
+	String() string
+}
+
+type id string
+
+func (e id) Eval(changed func(string) bool) bool { return changed(string(e)) }
+func (e id) String() string                      { return string(e) }
+
+type not struct{ operand Expr }
+
+func (e not) Eval(changed func(string) bool) bool { return !e.operand.Eval(changed) }
+func (e not) String() string {
+	if _, ok := e.operand.(id); ok {
+		return "!" + e.operand.String()
+	}
+	if _, ok := e.operand.(not); ok {
+		return "!" + e.operand.String()
+	}
+	return "!(" + e.operand.String() + ")"
+}
+
+type and []Expr
+
+func (e and) Eval(changed func(string) bool) bool {
+	for _, operand := range e {
+		if !operand.Eval(changed) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e and) String() string {
+	parts := make([]string, len(e))
+	for i, operand := range e {
+		parts[i] = operand.String()
+		if _, ok := operand.(or); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " && ")
+}
+
+type or []Expr
+
+func (e or) Eval(changed func(string) bool) bool {
+	for _, operand := range e {
+		if operand.Eval(changed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e or) String() string {
+	parts := make([]string, len(e))
+	for i, operand := range e {
+		parts[i] = operand.String()
+	}
+	return strings.Join(parts, " || ")
+}
+
+// IDs returns the resource IDs the expression refers to, in order of first use
+func IDs(e Expr) []string {
+	var ids []string
+	seen := map[string]bool{}
+	var walk func(Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case id:
+			if !seen[string(e)] {
+				seen[string(e)] = true
+				ids = append(ids, string(e))
+			}
+		case not:
+			walk(e.operand)
+		case and:
+			for _, operand := range e {
+				walk(operand)
+			}
+		case or:
+			for _, operand := range e {
+				walk(operand)
+			}
+		}
+	}
+	walk(e)
+	return ids
+}
+
+// FiredClause returns the first clause of the top-level `||` that is true, or the whole expression when it has no
+// top-level `||`, and false when the expression is false
+func FiredClause(e Expr, changed func(id string) bool) (Expr, bool) {
+	clauses, ok := e.(or)
+	if !ok {
+		return e, e.Eval(changed)
+	}
+	for _, clause := range clauses {
+		if clause.Eval(changed) {
+			return clause, true
+		}
+	}
+	return nil, false
+}
+
+// Parse parses an expression
+func Parse(s string) (Expr, error) {
+	p := &parser{input: s}
+	p.next()
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		return nil, p.unexpected()
+	}
+	return e, nil
+}
+
+// parser is a recursive descent parser reading one token ahead
+type parser struct {
+	input string
+	// pos is the offset of the unread input, start the offset of token
+	pos, start int
+	// token is the current token, empty at the end of the input
+	token string
+	err   error
+	// depth is the nesting of the operand being parsed
+	depth int
+}
+
+// next reads the next token
+func (p *parser) next() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\n') {
+		p.pos++
+	}
+	p.start = p.pos
+	rest := p.input[p.pos:]
+	switch {
+	case rest == "":
+		p.token = ""
+	case strings.HasPrefix(rest, "&&"), strings.HasPrefix(rest, "||"):
+		p.token = rest[:2]
+	case rest[0] == '!' || rest[0] == '(' || rest[0] == ')':
+		p.token = rest[:1]
+	default:
+		end := strings.IndexFunc(rest, func(r rune) bool {
+			return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		})
+		if end == 0 {
+			p.token = rest[:1]
+			p.err = fmt.Errorf("invalid character %q at position %d", rest[0], p.pos)
+		} else if end < 0 {
+			p.token = rest
+		} else {
+			p.token = rest[:end]
+		}
+	}
+	p.pos += len(p.token)
+}
+
+func (p *parser) unexpected() error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.token == "" {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at position %d", p.token, p.start)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	operands, err := p.parseOperands("||", p.parseAnd)
+	if err != nil || len(operands) == 1 {
+		return first(operands), err
+	}
+	return or(operands), nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	operands, err := p.parseOperands("&&", p.parseUnary)
+	if err != nil || len(operands) == 1 {
+		return first(operands), err
+	}
+	return and(operands), nil
+}
+
+// parseOperands parses operands separated by op
+func (p *parser) parseOperands(op string, parse func() (Expr, error)) ([]Expr, error) {
+	var operands []Expr
+	for {
+		e, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, e)
+		if p.token != op {
+			return operands, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.token == "!" || p.token == "(" {
+		if p.depth >= MaxDepth {
+			return nil, fmt.Errorf("expression nested deeper than %d at position %d", MaxDepth, p.start)
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+	}
+
+	switch {
+	case p.err != nil:
+		return nil, p.err
+	case p.token == "!":
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not{e}, nil
+	case p.token == "(":
+		p.next()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			return nil, p.unexpected()
+		}
+		p.next()
+		return e, nil
+	case IDPattern.MatchString(p.token):
+		e := id(p.token)
+		p.next()
+		return e, nil
+	default:
+		return nil, p.unexpected()
+	}
+}
+
+func first(operands []Expr) Expr {
+	if len(operands) == 0 {
+		return nil
+	}
+	return operands[0]
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expression
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func changedSet(ids ...string) func(string) bool {
+	return func(id string) bool { return slices.Contains(ids, id) }
+}
+
+func TestParseAndEval(t *testing.T) {
+	tests := []struct {
+		expression string
+		rendered   string
+		changed    []string
+		expected   bool
+	}{
+		{expression: "a", rendered: "a", changed: []string{"a"}, expected: true},
+		{expression: "a", rendered: "a", expected: false},
+		{expression: "(dbConfig && dbSecret) || featureFlags", rendered: "dbConfig && dbSecret || featureFlags", changed: []string{"dbConfig"}, expected: false},
+		{expression: "(dbConfig && dbSecret) || featureFlags", rendered: "dbConfig && dbSecret || featureFlags", changed: []string{"dbConfig", "dbSecret"}, expected: true},
+		{expression: "(dbConfig && dbSecret) || featureFlags", rendered: "dbConfig && dbSecret || featureFlags", changed: []string{"featureFlags"}, expected: true},
+		{expression: "a && (b || c)", rendered: "a && (b || c)", changed: []string{"a", "c"}, expected: true},
+		{expression: "a || b && c", rendered: "a || b && c", changed: []string{"b"}, expected: false},
+		{expression: "a && !b", rendered: "a && !b", changed: []string{"a"}, expected: true},
+		{expression: "!(a || b)", rendered: "!(a || b)", changed: []string{"b"}, expected: false},
+		{expression: "  a&&b_2\n", rendered: "a && b_2", changed: []string{"a", "b_2"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			e, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if e.String() != tt.rendered {
+				t.Errorf("Expected %q to render as %q, got %q", tt.expression, tt.rendered, e.String())
+			}
+			if got := e.Eval(changedSet(tt.changed...)); got != tt.expected {
+				t.Errorf("Expected %q to be %t with %v changed, got %t", tt.expression, tt.expected, tt.changed, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"":         "unexpected end of expression",
+		"a &&":     "unexpected end of expression",
+		"a b":      `unexpected "b" at position 2`,
+		"(a || b":  "unexpected end of expression",
+		"a || )":   `unexpected ")" at position 5`,
+		"a & b":    `invalid character '&' at position 2`,
+		"a && 1b":  `unexpected "1b" at position 5`,
+		"a-config": `invalid character '-' at position 1`,
+	}
+
+	for expression, expected := range tests {
+		t.Run(expression, func(t *testing.T) {
+			_, err := Parse(expression)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected error to contain %q, got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestParseDepth(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + "a" + strings.Repeat(")", depth)
+	}
+
+	if _, err := Parse(nested(MaxDepth)); err != nil {
+		t.Errorf("Expected %d nested parentheses to parse, got %v", MaxDepth, err)
+	}
+	if _, err := Parse(strings.Repeat("!", MaxDepth) + "a"); err != nil {
+		t.Errorf("Expected %d negations to parse, got %v", MaxDepth, err)
+	}
+
+	for name, expression := range map[string]string{
+		"parentheses": nested(MaxDepth + 1),
+		"negations":   strings.Repeat("!", MaxDepth+1) + "a",
+		"mixed":       strings.Repeat("!(", MaxDepth/2) + "!a" + strings.Repeat(")", MaxDepth/2),
+		"unbalanced":  strings.Repeat("(", 100000),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(expression)
+			if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("nested deeper than %d", MaxDepth)) {
+				t.Errorf("Expected a nesting error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestIDsAndFiredClause(t *testing.T) {
+	e, err := Parse("(dbConfig && dbSecret) || featureFlags || !dbConfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := IDs(e); !slices.Equal(ids, []string{"dbConfig", "dbSecret", "featureFlags"}) {
+		t.Errorf("Expected IDs dbConfig, dbSecret and featureFlags, got %v", ids)
+	}
+
+	clause, ok := FiredClause(e, changedSet("dbConfig", "featureFlags"))
+	if !ok || clause.String() != "featureFlags" {
+		t.Errorf("Expected clause featureFlags to fire, got %v", clause)
+	}
+	if _, ok := FiredClause(e, changedSet("dbConfig")); ok {
+		t.Error("Expected no clause to fire")
+	}
+
+	single, err := Parse("a && b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clause, ok := FiredClause(single, changedSet("a", "b")); !ok || clause.String() != "a && b" {
+		t.Errorf("Expected the whole expression to fire, got %v", clause)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates ChangeJobPolicies against ChangeTriggeredJobs, shared by the webhook and the reconciler.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+// Check returns the violations of every ChangeJobPolicy selecting the ChangeTriggeredJob's namespace. Policies with an
+// invalid namespaceSelector are skipped and returned as errors, so one broken policy does not block every
+// ChangeTriggeredJob.
+func Check(ctx context.Context, c client.Reader, obj *triggersv1alpha.ChangeTriggeredJob) (field.ErrorList, []error, error) {
+	var policies triggersv1alpha.ChangeJobPolicyList
+	if err := c.List(ctx, &policies); err != nil {
+		return nil, nil, fmt.Errorf("unable to list ChangeJobPolicies: %w", err)
+	}
+	if len(policies.Items) == 0 {
+		return nil, nil, nil
+	}
+
+	var ns corev1.Namespace
+	if err := c.Get(ctx, client.ObjectKey{Name: obj.Namespace}, &ns); err != nil {
+		return nil, nil, fmt.Errorf("unable to get namespace %s: %w", obj.Namespace, err)
+	}
+
+	var allErrs field.ErrorList
+	var invalid []error
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		selected, err := Selects(policy, ns.Labels)
+		if err != nil {
+			logf.FromContext(ctx).Error(err, "Skipping ChangeJobPolicy with an invalid namespaceSelector", "policy", policy.Name)
+			invalid = append(invalid, fmt.Errorf("ChangeJobPolicy %s has an invalid namespaceSelector: %w", policy.Name, err))
+			continue
+		}
+		if selected {
+			allErrs = append(allErrs, Evaluate(policy, obj)...)
+		}
+	}
+	return allErrs, invalid, nil
+}
+
+// Selects reports whether the policy applies to a namespace with the given labels
+func Selects(policy *triggersv1alpha.ChangeJobPolicy, namespaceLabels map[string]string) (bool, error) {
+	if policy.Spec.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(namespaceLabels)), nil
+}
+
+// Evaluate returns the violations of a single policy, regardless of its namespace selector
+func Evaluate(policy *triggersv1alpha.ChangeJobPolicy, obj *triggersv1alpha.ChangeTriggeredJob) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	resourcesPath := specPath.Child("resources")
+	spec := policy.Spec
+
+	if spec.MaxResources != nil && len(obj.Spec.Resources) > int(*spec.MaxResources) {
+		allErrs = append(allErrs, field.Forbidden(resourcesPath,
+			fmt.Sprintf("ChangeJobPolicy %s allows at most %d resources, got %d", policy.Name, *spec.MaxResources, len(obj.Spec.Resources))))
+	}
+
+	for i, ref := range obj.Spec.Resources {
+		if len(spec.AllowedKinds) > 0 {
+			gv, err := schema.ParseGroupVersion(ref.APIVersion)
+			if err == nil && !slices.ContainsFunc(spec.AllowedKinds, func(k triggersv1alpha.PolicyGroupKind) bool {
+				return (k.Group == "*" || k.Group == gv.Group) && (k.Kind == "*" || k.Kind == ref.Kind)
+			}) {
+				allErrs = append(allErrs, field.Forbidden(resourcesPath.Index(i),
+					fmt.Sprintf("ChangeJobPolicy %s does not allow watching %s", policy.Name, gv.WithKind(ref.Kind).GroupKind())))
+			}
+		}
+
+		if spec.AllowCrossNamespace != nil && !*spec.AllowCrossNamespace && ref.Namespace != obj.Namespace {
+			allErrs = append(allErrs, field.Forbidden(resourcesPath.Index(i).Child("namespace"),
+				fmt.Sprintf("ChangeJobPolicy %s only allows resources in namespace %s", policy.Name, obj.Namespace)))
+		}
+	}
+
+	if spec.MinCooldown != nil {
+		var cooldown metav1.Duration
+		if obj.Spec.Cooldown != nil {
+			cooldown = *obj.Spec.Cooldown
+		}
+		if cooldown.Duration < spec.MinCooldown.Duration {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("cooldown"),
+				fmt.Sprintf("ChangeJobPolicy %s requires a cooldown of at least %s, got %s", policy.Name, spec.MinCooldown.Duration, cooldown.Duration)))
+		}
+	}
+
+	if spec.MaxHistory != nil && obj.Spec.History != nil && *obj.Spec.History > *spec.MaxHistory {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("history"),
+			fmt.Sprintf("ChangeJobPolicy %s allows a history of at most %d, got %d", policy.Name, *spec.MaxHistory, *obj.Spec.History)))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+const (
+	testNamespace = "team-a"
+	testPolicy    = "tenants"
+)
+
+func testChangeJob() *triggersv1alpha.ChangeTriggeredJob {
+	return &triggersv1alpha.ChangeTriggeredJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: testNamespace},
+		Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+			Resources: []triggersv1alpha.ResourceReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "config", Namespace: testNamespace},
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", Namespace: testNamespace},
+			},
+			Cooldown: &metav1.Duration{Duration: 10 * time.Minute},
+			History:  ptr.To(int32(5)),
+		},
+	}
+}
+
+func violationFields(t *testing.T, policy triggersv1alpha.ChangeJobPolicySpec, obj *triggersv1alpha.ChangeTriggeredJob) []string {
+	t.Helper()
+	errs := Evaluate(&triggersv1alpha.ChangeJobPolicy{ObjectMeta: metav1.ObjectMeta{Name: testPolicy}, Spec: policy}, obj)
+	fields := make([]string, 0, len(errs))
+	for _, err := range errs {
+		if !strings.Contains(err.Detail, testPolicy) {
+			t.Errorf("Expected violation to name the policy, got %q", err.Detail)
+		}
+		fields = append(fields, err.Field)
+	}
+	return fields
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   triggersv1alpha.ChangeJobPolicySpec
+		modify   func(*triggersv1alpha.ChangeTriggeredJob)
+		expected []string
+	}{
+		{
+			name:   "empty policy allows everything",
+			policy: triggersv1alpha.ChangeJobPolicySpec{},
+		},
+		{
+			name: "allowed kinds with wildcards",
+			policy: triggersv1alpha.ChangeJobPolicySpec{
+				AllowedKinds: []triggersv1alpha.PolicyGroupKind{{Group: "", Kind: "ConfigMap"}, {Group: "apps", Kind: "*"}},
+			},
+		},
+		{
+			name: "kind not allowed",
+			policy: triggersv1alpha.ChangeJobPolicySpec{
+				AllowedKinds: []triggersv1alpha.PolicyGroupKind{{Group: "", Kind: "ConfigMap"}},
+			},
+			expected: []string{"spec.resources[1]"},
+		},
+		{
+			name: "cross-namespace and cluster-scoped references",
+			policy: triggersv1alpha.ChangeJobPolicySpec{
+				AllowCrossNamespace: ptr.To(false),
+			},
+			modify: func(obj *triggersv1alpha.ChangeTriggeredJob) {
+				obj.Spec.Resources[0].Namespace = "kube-system"
+				obj.Spec.Resources = append(obj.Spec.Resources, triggersv1alpha.ResourceReference{APIVersion: "v1", Kind: "Node", Name: "node-1"})
+			},
+			expected: []string{"spec.resources[0].namespace", "spec.resources[2].namespace"},
+		},
+		{
+			name: "too many resources",
+			policy: triggersv1alpha.ChangeJobPolicySpec{
+				MaxResources: ptr.To(int32(1)),
+			},
+			expected: []string{"spec.resources"},
+		},
+		{
+			name: "cooldown and history limits",
+			policy: triggersv1alpha.ChangeJobPolicySpec{
+				MinCooldown: &metav1.Duration{Duration: time.Hour},
+				MaxHistory:  ptr.To(int32(3)),
+			},
+			expected: []string{"spec.cooldown", "spec.history"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := testChangeJob()
+			if tt.modify != nil {
+				tt.modify(obj)
+			}
+			fields := violationFields(t, tt.policy, obj)
+			if strings.Join(fields, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected violations %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := triggersv1alpha.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: map[string]string{"tenant": "true"}}}
+	selected := &triggersv1alpha.ChangeJobPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: testPolicy},
+		Spec: triggersv1alpha.ChangeJobPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "true"}},
+			MaxResources:      ptr.To(int32(1)),
+		},
+	}
+	notSelected := &triggersv1alpha.ChangeJobPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "system"},
+		Spec: triggersv1alpha.ChangeJobPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"system": "true"}},
+			MaxHistory:        ptr.To(int32(1)),
+		},
+	}
+
+	t.Run("no policies", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		errs, invalid, err := Check(context.Background(), c, testChangeJob())
+		if err != nil || len(errs) != 0 || len(invalid) != 0 {
+			t.Errorf("Expected no violations, got %v, %v, %v", errs, invalid, err)
+		}
+	})
+
+	t.Run("only selected policies apply", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace, selected, notSelected).Build()
+		errs, invalid, err := Check(context.Background(), c, testChangeJob())
+		if err != nil || len(invalid) != 0 {
+			t.Fatalf("Expected no error, got %v, %v", invalid, err)
+		}
+		if len(errs) != 1 || errs[0].Field != "spec.resources" {
+			t.Errorf("Expected a single spec.resources violation, got %v", errs)
+		}
+	})
+
+	t.Run("invalid policies are skipped", func(t *testing.T) {
+		broken := &triggersv1alpha.ChangeJobPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+			Spec: triggersv1alpha.ChangeJobPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tenant", Operator: metav1.LabelSelectorOpIn},
+				}},
+				MaxHistory: ptr.To(int32(1)),
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(namespace, selected, broken).Build()
+		errs, invalid, err := Check(context.Background(), c, testChangeJob())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(errs) != 1 || errs[0].Field != "spec.resources" {
+			t.Errorf("Expected a single spec.resources violation, got %v", errs)
+		}
+		if len(invalid) != 1 || !strings.Contains(invalid[0].Error(), "ChangeJobPolicy broken") {
+			t.Errorf("Expected the broken policy to be reported, got %v", invalid)
+		}
+	})
+
+	t.Run("missing namespace", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(selected).Build()
+		if _, _, err := Check(context.Background(), c, testChangeJob()); err == nil {
+			t.Error("Expected an error, got nil")
+		}
+	})
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding splits ChangeTriggeredJobs between controller replicas.
+// Every replica renews its own Lease, the live Leases form the member list, and each of a fixed number of shards is
+// assigned to a member by rendezvous (highest random weight) hashing, so only the shards of a joining or leaving
+// replica move. A replica acts on a shard it gains only after a handoff delay, by which time the previous owner has
+// seen the new members and stopped, so two replicas never reconcile the same ChangeTriggeredJob at once.
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	shardMembers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "changejob_shard_members",
+		Help: "Number of live controller replicas sharing the shards",
+	})
+
+	shardsOwned = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "changejob_shards_owned",
+		Help: "Number of shards owned by this replica",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(shardMembers, shardsOwned)
+}
+
+const (
+	// GroupLabel marks the Leases of the replicas sharing the shards
+	GroupLabel = "changejob.dev/shard-group"
+
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewInterval = 5 * time.Second
+)
+
+// Sharder maintains the replica's Lease and decides which ChangeTriggeredJobs the replica owns
+type Sharder struct {
+	Client client.Client
+	// Uncached reader for the member Leases
+	Reader client.Reader
+	// Namespace of the Leases
+	Namespace string
+	// Name shared by the replicas, prefixes the Lease names
+	Group string
+	// Unique name of this replica, e.g., the pod name
+	Identity string
+	// Number of shards, fixed for the lifetime of the group
+	Shards        int
+	LeaseDuration time.Duration
+	RenewInterval time.Duration
+	// Delay before acting on a newly gained shard, at least RenewInterval so the previous owner has synced,
+	// LeaseDuration by default so a previous owner that cannot reach the API server has stopped too
+	Handoff time.Duration
+
+	// Rebalanced receives an event whenever the members change, buffered so a slow consumer only misses duplicates
+	Rebalanced chan event.GenericEvent
+
+	mu      sync.RWMutex
+	members []string
+	// Time each newly gained shard can be acted on, removed once it is reached
+	handoffs  map[int]time.Time
+	lastRenew time.Time
+	lastSync  time.Time
+}
+
+// NewSharder returns a sharder with the default lease timings
+func NewSharder(c client.Client, reader client.Reader, namespace, group, identity string, shards int) *Sharder {
+	return &Sharder{
+		Client:        c,
+		Reader:        reader,
+		Namespace:     namespace,
+		Group:         group,
+		Identity:      identity,
+		Shards:        shards,
+		LeaseDuration: DefaultLeaseDuration,
+		RenewInterval: DefaultRenewInterval,
+		Handoff:       DefaultLeaseDuration,
+		Rebalanced:    make(chan event.GenericEvent, 1),
+	}
+}
+
+// ShardOf returns the shard of a key, e.g., namespace/name
+func ShardOf(key string, shards int) int {
+	return int(hash(key) % uint64(shards))
+}
+
+// Assign returns the member owning a shard, the member with the highest hash of member and shard
+func Assign(shard int, members []string) string {
+	var owner string
+	var best uint64
+	for _, member := range members {
+		if weight := hash(member + "/" + strconv.Itoa(shard)); owner == "" || weight > best {
+			owner, best = member, weight
+		}
+	}
+	return owner
+}
+
+// hash returns FNV-1a of s with a final avalanche, so similar strings such as replica names get unrelated weights
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Owns reports whether this replica owns the key, always false until the first Lease renewal and during the handoff
+// of a newly gained shard
+func (s *Sharder) Owns(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	shard := ShardOf(key, s.Shards)
+	if Assign(shard, s.members) != s.Identity {
+		return false
+	}
+	ready, handoff := s.handoffs[shard]
+	return !handoff || !time.Now().Before(ready)
+}
+
+// Members returns the live replicas
+func (s *Sharder) Members() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.members)
+}
+
+// NeedLeaderElection runs the sharder on every replica
+func (s *Sharder) NeedLeaderElection() bool {
+	return false
+}
+
+// Start renews the Lease and refreshes the members until the context is done, it implements manager.Runnable
+func (s *Sharder) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("sharding")
+	ticker := time.NewTicker(s.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sync(ctx); err != nil {
+			log.Error(err, "Failed to sync shard members")
+		}
+		s.completeHandoffs(time.Now())
+
+		select {
+		case <-ctx.Done():
+			// Release the shards right away instead of after the lease expires
+			releaseCtx, cancel := context.WithTimeout(context.Background(), s.RenewInterval)
+			defer cancel()
+			if err := s.Client.Delete(releaseCtx, &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: s.leaseName(), Namespace: s.Namespace}}); client.IgnoreNotFound(err) != nil {
+				log.Error(err, "Failed to release shard lease")
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sharder) leaseName() string {
+	return s.Group + "-shard-" + s.Identity
+}
+
+// sync renews the Lease and updates the members, signalling a rebalance when they change
+func (s *Sharder) sync(ctx context.Context) error {
+	now := metav1.NewMicroTime(time.Now())
+	if err := s.renew(ctx, now); err != nil {
+		// Stop owning shards once the Lease may have expired for the other replicas
+		if !s.lastRenew.IsZero() && now.Sub(s.lastRenew) >= s.LeaseDuration {
+			s.setMembers(ctx, nil)
+		}
+		return err
+	}
+	s.lastRenew = now.Time
+
+	var leases coordinationv1.LeaseList
+	if err := s.Reader.List(ctx, &leases, client.InNamespace(s.Namespace), client.MatchingLabels{GroupLabel: s.Group}); err != nil {
+		// Stop owning shards once other replicas may have taken them over without this replica noticing
+		if !s.lastSync.IsZero() && now.Sub(s.lastSync) >= s.LeaseDuration {
+			s.setMembers(ctx, nil)
+		}
+		return fmt.Errorf("unable to list shard leases: %w", err)
+	}
+	s.lastSync = now.Time
+
+	members := []string{s.Identity}
+	for _, lease := range leases.Items {
+		holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+		if holder == "" || holder == s.Identity || lease.Spec.RenewTime == nil {
+			continue
+		}
+		duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second
+		if lease.Spec.RenewTime.Add(duration).After(now.Time) {
+			members = append(members, holder)
+		}
+	}
+	slices.Sort(members)
+	s.setMembers(ctx, members)
+	return nil
+}
+
+// renew creates or renews the replica's Lease
+func (s *Sharder) renew(ctx context.Context, now metav1.MicroTime) error {
+	var lease coordinationv1.Lease
+	err := s.Reader.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.leaseName()}, &lease)
+	if apierrors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.leaseName(),
+				Namespace: s.Namespace,
+				Labels:    map[string]string{GroupLabel: s.Group},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(s.Identity),
+				LeaseDurationSeconds: ptr.To(int32(s.LeaseDuration / time.Second)),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if err := s.Client.Create(ctx, &lease); err != nil {
+			return fmt.Errorf("unable to create shard lease: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get shard lease: %w", err)
+	}
+
+	lease.Spec.HolderIdentity = ptr.To(s.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(s.LeaseDuration / time.Second))
+	lease.Spec.RenewTime = &now
+	if err := s.Client.Update(ctx, &lease); err != nil {
+		return fmt.Errorf("unable to renew shard lease: %w", err)
+	}
+	return nil
+}
+
+// setMembers updates the members, starting the handoff of the shards gained from other replicas
+func (s *Sharder) setMembers(ctx context.Context, members []string) {
+	s.mu.Lock()
+	previous := s.members
+	changed := !slices.Equal(previous, members)
+	s.members = members
+	owned := 0
+	if changed {
+		ready := time.Now().Add(s.Handoff)
+		if s.handoffs == nil {
+			s.handoffs = make(map[int]time.Time)
+		}
+		for shard := range s.Shards {
+			switch {
+			case Assign(shard, members) != s.Identity:
+				delete(s.handoffs, shard)
+			case Assign(shard, previous) != s.Identity && s.Handoff > 0:
+				owned++
+				s.handoffs[shard] = ready
+			default:
+				owned++
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	logf.FromContext(ctx).WithName("sharding").Info("Shard members changed", "members", members, "identity", s.Identity, "handoff", s.Handoff)
+	shardMembers.Set(float64(len(members)))
+	shardsOwned.Set(float64(owned))
+	s.rebalance()
+}
+
+// completeHandoffs ends the handoffs that are over, signalling a rebalance so their ChangeTriggeredJobs are requeued
+func (s *Sharder) completeHandoffs(now time.Time) {
+	s.mu.Lock()
+	completed := false
+	for shard, ready := range s.handoffs {
+		if !now.Before(ready) {
+			delete(s.handoffs, shard)
+			completed = true
+		}
+	}
+	s.mu.Unlock()
+
+	if completed {
+		s.rebalance()
+	}
+}
+
+// rebalance signals that the owned ChangeTriggeredJobs changed, without blocking
+func (s *Sharder) rebalance() {
+	select {
+	case s.Rebalanced <- event.GenericEvent{Object: &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: s.leaseName(), Namespace: s.Namespace}}}:
+	default:
+	}
+}
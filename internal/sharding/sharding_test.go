@@ -0,0 +1,174 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testNamespace = "kube-changejob-system"
+	testGroup     = "kube-changejob"
+	testShards    = 16
+)
+
+func testKeys() []string {
+	keys := make([]string, 0, 1000)
+	for i := range 1000 {
+		keys = append(keys, fmt.Sprintf("team-%d/job-%d", i%10, i))
+	}
+	return keys
+}
+
+func TestAssign(t *testing.T) {
+	members := []string{"replica-0", "replica-1", "replica-2"}
+
+	counts := make(map[string]int)
+	for shard := range testShards {
+		counts[Assign(shard, members)]++
+	}
+	for _, member := range members {
+		if counts[member] == 0 {
+			t.Errorf("Expected %s to own at least one shard, got %v", member, counts)
+		}
+	}
+
+	// Only the shards of the leaving member move
+	for shard := range testShards {
+		before := Assign(shard, members)
+		after := Assign(shard, members[:2])
+		if before != "replica-2" && before != after {
+			t.Errorf("Expected shard %d to stay on %s, moved to %s", shard, before, after)
+		}
+	}
+
+	if owner := Assign(0, nil); owner != "" {
+		t.Errorf("Expected no owner without members, got %q", owner)
+	}
+}
+
+func TestShardOf(t *testing.T) {
+	for _, key := range testKeys() {
+		shard := ShardOf(key, testShards)
+		if shard < 0 || shard >= testShards {
+			t.Fatalf("Expected shard of %s in [0, %d), got %d", key, testShards, shard)
+		}
+		if ShardOf(key, testShards) != shard {
+			t.Fatalf("Expected shard of %s to be stable", key)
+		}
+	}
+}
+
+func TestSharder(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	stale := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: testGroup + "-shard-gone", Namespace: testNamespace, Labels: map[string]string{GroupLabel: testGroup}},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To("gone"),
+			LeaseDurationSeconds: ptr.To(int32(15)),
+			RenewTime:            &metav1.MicroTime{Time: time.Now().Add(-time.Minute)},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(stale).Build()
+	a := NewSharder(c, c, testNamespace, testGroup, "replica-a", testShards)
+	b := NewSharder(c, c, testNamespace, testGroup, "replica-b", testShards)
+
+	if a.Owns("team-a/job") {
+		t.Error("Expected no ownership before the first sync")
+	}
+
+	for _, s := range []*Sharder{a, b, a} {
+		if err := s.sync(ctx); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if members := a.Members(); len(members) != 2 || members[0] != "replica-a" || members[1] != "replica-b" {
+		t.Errorf("Expected the live replicas as members, got %v", members)
+	}
+	select {
+	case <-a.Rebalanced:
+	default:
+		t.Error("Expected a rebalance event after the members changed")
+	}
+
+	// Newly gained shards are only acted on once their handoff is over
+	for _, key := range testKeys() {
+		if a.Owns(key) || b.Owns(key) {
+			t.Fatalf("Expected %s not to be owned during the handoff", key)
+		}
+	}
+	<-b.Rebalanced
+	for _, s := range []*Sharder{a, b} {
+		s.completeHandoffs(time.Now())
+		select {
+		case <-s.Rebalanced:
+			t.Error("Expected no rebalance event before the handoff is over")
+		default:
+		}
+		s.completeHandoffs(time.Now().Add(s.Handoff))
+		select {
+		case <-s.Rebalanced:
+		default:
+			t.Error("Expected a rebalance event once the handoff is over")
+		}
+	}
+
+	owned := map[string]int{}
+	for _, key := range testKeys() {
+		switch {
+		case a.Owns(key) && b.Owns(key):
+			t.Fatalf("Expected %s to be owned by one replica, owned by both", key)
+		case a.Owns(key):
+			owned["replica-a"]++
+		case b.Owns(key):
+			owned["replica-b"]++
+		default:
+			t.Fatalf("Expected %s to be owned by a replica", key)
+		}
+	}
+	if owned["replica-a"] == 0 || owned["replica-b"] == 0 {
+		t.Errorf("Expected both replicas to own keys, got %v", owned)
+	}
+
+	// The remaining replica takes over every key once the other releases its Lease
+	if err := c.Delete(ctx, &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: b.leaseName(), Namespace: testNamespace}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.sync(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, key := range testKeys() {
+		if a.Owns(key) && b.Owns(key) {
+			t.Fatalf("Expected %s to be owned by one replica during the handoff, owned by both", key)
+		}
+	}
+	a.completeHandoffs(time.Now().Add(a.Handoff))
+	for _, key := range testKeys() {
+		if !a.Owns(key) {
+			t.Fatalf("Expected replica-a to own %s", key)
+		}
+	}
+
+	var lease coordinationv1.Lease
+	if err := c.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: a.leaseName()}, &lease); err != nil {
+		t.Fatalf("Expected the replica's lease to exist, got %v", err)
+	}
+	if lease.Labels[GroupLabel] != testGroup || ptr.Deref(lease.Spec.HolderIdentity, "") != "replica-a" {
+		t.Errorf("Expected lease held by replica-a in group %s, got %+v", testGroup, lease)
+	}
+}
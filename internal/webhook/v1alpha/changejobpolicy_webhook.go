@@ -0,0 +1,71 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+// SetupChangeJobPolicyWebhookWithManager registers the webhook for ChangeJobPolicy in the manager.
+func SetupChangeJobPolicyWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr, &triggersv1alpha.ChangeJobPolicy{}).
+		WithValidator(&ChangeJobPolicyCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-triggers-changejob-dev-v1alpha-changejobpolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=triggers.changejob.dev,resources=changejobpolicies,verbs=create;update,versions=v1alpha,name=vchangejobpolicy-v1alpha.kb.io,admissionReviewVersions=v1
+
+// ChangeJobPolicyCustomValidator struct is responsible for validating the ChangeJobPolicy resource
+// when it is created, updated, or deleted.
+type ChangeJobPolicyCustomValidator struct{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type ChangeJobPolicy.
+func (v *ChangeJobPolicyCustomValidator) ValidateCreate(_ context.Context, obj *triggersv1alpha.ChangeJobPolicy) (admission.Warnings, error) {
+	log.Info("Validation for ChangeJobPolicy upon creation", "name", obj.GetName())
+	return nil, v.validate(obj)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type ChangeJobPolicy.
+func (v *ChangeJobPolicyCustomValidator) ValidateUpdate(_ context.Context, _, newObj *triggersv1alpha.ChangeJobPolicy) (admission.Warnings, error) {
+	log.Info("Validation for ChangeJobPolicy upon update", "name", newObj.GetName())
+	return nil, v.validate(newObj)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type ChangeJobPolicy.
+func (v *ChangeJobPolicyCustomValidator) ValidateDelete(_ context.Context, obj *triggersv1alpha.ChangeJobPolicy) (admission.Warnings, error) {
+	log.Info("Validation for ChangeJobPolicy upon deletion", "name", obj.GetName())
+	return nil, nil
+}
+
+// validate rejects namespace selectors that cannot be converted to a label selector, which would otherwise be
+// skipped when enforcing policies
+func (v *ChangeJobPolicyCustomValidator) validate(obj *triggersv1alpha.ChangeJobPolicy) error {
+	allErrs := metav1validation.ValidateLabelSelector(obj.Spec.NamespaceSelector, metav1validation.LabelSelectorValidationOptions{},
+		field.NewPath("spec", "namespaceSelector"))
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(triggersv1alpha.GroupVersion.WithKind("ChangeJobPolicy").GroupKind(), obj.Name, allErrs)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+)
+
+var _ = Describe("ChangeJobPolicy Webhook", func() {
+	var (
+		obj       *triggersv1alpha.ChangeJobPolicy
+		validator ChangeJobPolicyCustomValidator
+		ctx       context.Context
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		obj = &triggersv1alpha.ChangeJobPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy-webhook-test"},
+		}
+		validator = ChangeJobPolicyCustomValidator{}
+	})
+
+	Context("When creating or updating ChangeJobPolicy under Validating Webhook", func() {
+		It("Should admit a valid namespaceSelector", func() {
+			obj.Spec.NamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"tenant": "true"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+				},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Admitting a policy without a namespaceSelector")
+			obj.Spec.NamespaceSelector = nil
+			_, err = validator.ValidateUpdate(ctx, obj, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny an invalid namespaceSelector", func() {
+			obj.Spec.NamespaceSelector = &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tenant", Operator: metav1.LabelSelectorOpIn},
+					{Key: "team", Operator: metav1.LabelSelectorOpExists, Values: []string{"a"}},
+				},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.namespaceSelector.matchExpressions[0].values"))
+			Expect(err.Error()).To(ContainSubstring("spec.namespaceSelector.matchExpressions[1].values"))
+
+			By("Denying the policy through the API server")
+			err = k8sClient.Create(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+
+			By("Denying an invalid label value")
+			obj.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "not a label"}}
+			_, err = validator.ValidateUpdate(ctx, obj, obj)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -19,18 +19,32 @@ package v1alpha
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/cloudevents"
+	"github.com/nusnewob/kube-changejob/internal/config"
 	"github.com/nusnewob/kube-changejob/internal/controller"
+	"github.com/nusnewob/kube-changejob/internal/expression"
+	"github.com/nusnewob/kube-changejob/internal/policy"
 )
 
 // nolint:unused
@@ -38,17 +52,20 @@ import (
 var log = logf.Log.WithName("ChangeTriggeredJob-Webhook")
 
 // SetupChangeTriggeredJobWebhookWithManager registers the webhook for ChangeTriggeredJob in the manager.
-func SetupChangeTriggeredJobWebhookWithManager(mgr ctrl.Manager) error {
+func SetupChangeTriggeredJobWebhookWithManager(mgr ctrl.Manager, store *config.Store) error {
+	cfg := store.Get()
 	return ctrl.NewWebhookManagedBy(mgr, &triggersv1alpha.ChangeTriggeredJob{}).
 		WithValidator(&ChangeTriggeredJobCustomValidator{
-			Mapper: mgr.GetRESTMapper(),
-			Client: mgr.GetClient(),
+			Mapper:          mgr.GetRESTMapper(),
+			Client:          mgr.GetClient(),
+			Reader:          mgr.GetAPIReader(),
+			Config:          store,
+			WatchNamespaces: cfg.WatchNamespaces,
+			EnforcePolicies: cfg.EnforcePolicies,
 		}).
 		WithDefaulter(&ChangeTriggeredJobCustomDefaulter{
-			DefaultCooldown:        DefaultValues.DefaultCooldown,
-			DefaultCondition:       DefaultValues.DefaultCondition,
-			DefaultHistory:         DefaultValues.DefaultHistory,
 			ChangedAtAnnotationKey: DefaultValues.ChangedAtAnnotationKey,
+			Config:                 store,
 		}).
 		Complete()
 }
@@ -65,44 +82,60 @@ type ChangeTriggeredJobCustomDefaulter struct {
 	DefaultCondition       triggersv1alpha.TriggerCondition
 	DefaultHistory         int32
 	ChangedAtAnnotationKey string
+	// Optional: reloadable configuration, overrides the defaults above
+	Config *config.Store
 }
 
 var DefaultValues = ChangeTriggeredJobCustomDefaulter{
-	DefaultCooldown:        60 * time.Second,
-	DefaultCondition:       triggersv1alpha.TriggerConditionAny,
-	DefaultHistory:         5,
+	DefaultCooldown:        config.DefaultControllerConfig.DefaultCooldown,
+	DefaultCondition:       triggersv1alpha.TriggerCondition(config.DefaultControllerConfig.DefaultCondition),
+	DefaultHistory:         config.DefaultControllerConfig.DefaultHistory,
 	ChangedAtAnnotationKey: "changetriggeredjobs.triggers.changejob.dev/changed-at",
 }
 
 // Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind ChangeTriggeredJob.
 func (d *ChangeTriggeredJobCustomDefaulter) Default(ctx context.Context, obj *triggersv1alpha.ChangeTriggeredJob) error {
 	log.Info("Defaulting for ChangeTriggeredJob", "name", obj.GetName())
+	d.DefaultSpec(&obj.Spec)
 
-	// Optional: default cooldown if unset
-	if obj.Spec.Cooldown == nil {
-		obj.Spec.Cooldown = &metav1.Duration{Duration: DefaultValues.DefaultCooldown}
+	if obj.Annotations == nil {
+		obj.Annotations = make(map[string]string)
 	}
+	obj.Annotations[DefaultValues.ChangedAtAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
 
-	// Optional: default trigger condition if unset
-	if obj.Spec.Condition == nil {
-		obj.Spec.Condition = &DefaultValues.DefaultCondition
+	return nil
+}
+
+// DefaultSpec sets the configured cooldown, condition and history on the unset fields of spec. Clients reading
+// manifests without a cluster, such as the kubectl plugin, use it to default them as the webhook would.
+func (d *ChangeTriggeredJobCustomDefaulter) DefaultSpec(spec *triggersv1alpha.ChangeTriggeredJobSpec) {
+	defaults := DefaultValues
+	if d.Config != nil {
+		cfg := d.Config.Get()
+		defaults.DefaultCooldown = cfg.DefaultCooldown
+		defaults.DefaultCondition = triggersv1alpha.TriggerCondition(cfg.DefaultCondition)
+		defaults.DefaultHistory = cfg.DefaultHistory
 	}
 
-	// Optional: default history if unset
-	if obj.Spec.History == nil {
-		obj.Spec.History = &DefaultValues.DefaultHistory
+	// Optional: default cooldown if unset
+	if spec.Cooldown == nil {
+		spec.Cooldown = &metav1.Duration{Duration: defaults.DefaultCooldown}
 	}
 
-	if obj.Annotations == nil {
-		obj.Annotations = make(map[string]string)
+	// Optional: default trigger condition if unset
+	if spec.Condition == nil {
+		spec.Condition = &defaults.DefaultCondition
 	}
-	obj.Annotations[DefaultValues.ChangedAtAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
 
-	return nil
+	// Optional: default history if unset
+	if spec.History == nil {
+		spec.History = &defaults.DefaultHistory
+	}
 }
 
 // NOTE: If you want to customise the 'path', use the flags '--defaulting-path' or '--validation-path'.
 // +kubebuilder:webhook:path=/validate-triggers-changejob-dev-v1alpha-changetriggeredjob,mutating=false,failurePolicy=fail,sideEffects=None,groups=triggers.changejob.dev,resources=changetriggeredjobs,verbs=create;update,versions=v1alpha,name=vchangetriggeredjob-v1alpha.kb.io,admissionReviewVersions=v1
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
 
 // ChangeTriggeredJobCustomValidator struct is responsible for validating the ChangeTriggeredJob resource
 // when it is created, updated, or deleted.
@@ -113,76 +146,556 @@ type ChangeTriggeredJobCustomValidator struct {
 	Triggers []triggersv1alpha.ResourceReference
 	Mapper   meta.RESTMapper
 	Client   client.Client
+	// Uncached reader for watched objects, falls back to Client when unset
+	Reader client.Reader
+	// Controller poll interval, used to warn about ineffective cooldowns
+	PollInterval time.Duration
+	// Optional: reloadable configuration, overrides PollInterval, bounds spec.pollInterval and gates field warnings
+	Config *config.Store
+	// Namespaces watched by the controller, empty for all namespaces
+	WatchNamespaces []string
+	// Reject ChangeTriggeredJobs violating a ChangeJobPolicy
+	EnforcePolicies bool
 }
 
 // ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type ChangeTriggeredJob.
 func (v *ChangeTriggeredJobCustomValidator) ValidateCreate(ctx context.Context, obj *triggersv1alpha.ChangeTriggeredJob) (admission.Warnings, error) {
 	log.Info("Validation for ChangeTriggeredJob upon creation", "name", obj.GetName())
+	return v.validate(ctx, obj, nil)
+}
 
-	if len(obj.Spec.Resources) == 0 {
-		return nil, field.Invalid(
-			field.NewPath("spec").Child("resources"),
-			obj.Spec.Resources,
-			"at least one resource must be specified",
-		)
-	}
-
-	for i, ref := range obj.Spec.Resources {
-		_, err := controller.ValidateGVK(ctx, v.Mapper, ref.APIVersion, ref.Kind, ref.Namespace)
-		if err != nil {
-			return nil, field.Invalid(
-				field.NewPath("spec", "resources").Index(i),
-				fmt.Sprintf("%s/%s", ref.APIVersion, ref.Kind),
-				err.Error(),
-			)
-		}
+// validate checks the whole spec, access to resources and the ServiceAccount already referenced by oldObj, nil on
+// create, is not re-checked
+func (v *ChangeTriggeredJobCustomValidator) validate(ctx context.Context, obj, oldObj *triggersv1alpha.ChangeTriggeredJob) (admission.Warnings, error) {
+	specPath := field.NewPath("spec")
+	var existing []triggersv1alpha.ResourceReference
+	if oldObj != nil {
+		existing = oldObj.Spec.Resources
 	}
+	allErrs := v.validateResources(ctx, obj, existing)
 
 	if obj.Spec.Condition != nil {
 		validCondition := map[triggersv1alpha.TriggerCondition]struct{}{
-			triggersv1alpha.TriggerConditionAll: {},
-			triggersv1alpha.TriggerConditionAny: {},
+			triggersv1alpha.TriggerConditionAll:        {},
+			triggersv1alpha.TriggerConditionAny:        {},
+			triggersv1alpha.TriggerConditionAtLeast:    {},
+			triggersv1alpha.TriggerConditionExpression: {},
 		}
 		if _, ok := validCondition[*obj.Spec.Condition]; !ok {
-			return nil, field.Invalid(
-				field.NewPath("spec").Child("condition"),
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("condition"),
 				*obj.Spec.Condition,
-				"must be 'All' or 'Any'",
-			)
+				"must be 'All', 'Any', 'AtLeast' or 'Expression'",
+			))
 		}
 	}
+	allErrs = append(allErrs, validateMinChanged(obj)...)
+	allErrs = append(allErrs, validateExpression(obj)...)
 
 	if obj.Spec.History != nil && *obj.Spec.History < 1 {
-		return nil, field.Invalid(
-			field.NewPath("spec").Child("history"),
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("history"),
 			*obj.Spec.History,
 			"must be >= 1",
-		)
+		))
 	}
 
 	if obj.Spec.Cooldown != nil && obj.Spec.Cooldown.Duration < 0 {
-		return nil, field.Invalid(
-			field.NewPath("spec").Child("cooldown"),
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("cooldown"),
 			*obj.Spec.Cooldown,
 			"must be >= 0",
-		)
+		))
+	}
+
+	if obj.Spec.PollInterval != nil && obj.Spec.PollInterval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("pollInterval"),
+			*obj.Spec.PollInterval,
+			"must be > 0",
+		))
+	}
+
+	if obj.Spec.ChangeWindow != nil && obj.Spec.ChangeWindow.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("changeWindow"),
+			*obj.Spec.ChangeWindow,
+			"must be > 0",
+		))
+	}
+
+	if limit := obj.Spec.RateLimit; limit != nil && limit.Window.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("rateLimit", "window"),
+			limit.Window,
+			"must be > 0",
+		))
+	}
+
+	if detection := obj.Spec.FlappingDetection; detection != nil && detection.Window.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("flappingDetection", "window"),
+			detection.Window,
+			"must be > 0",
+		))
+	}
+
+	for i, window := range obj.Spec.TriggerWindows {
+		if err := controller.ValidateTriggerWindow(window); err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("triggerWindows").Index(i),
+				window,
+				err.Error(),
+			))
+		}
+	}
+
+	if obj.Spec.Schedule != "" {
+		if _, err := controller.ParseSchedule(obj.Spec.Schedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("schedule"),
+				obj.Spec.Schedule,
+				err.Error(),
+			))
+		}
+	}
+
+	if name := obj.Spec.ServiceAccountName; name != "" {
+		msgs := validation.IsDNS1123Subdomain(name)
+		for _, msg := range msgs {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("serviceAccountName"),
+				name,
+				msg,
+			))
+		}
+		if len(msgs) == 0 && (oldObj == nil || oldObj.Spec.ServiceAccountName != name) {
+			if err := v.authorizeServiceAccount(ctx, obj.Namespace, name, specPath.Child("serviceAccountName")); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+	}
+
+	if obj.Spec.EventSink != nil {
+		if u, err := url.Parse(obj.Spec.EventSink.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(
+				specPath.Child("eventSink", "url"),
+				obj.Spec.EventSink.URL,
+				"must be an absolute http or https URL",
+			))
+		} else if cfg := v.controllerConfig(); !cloudevents.AllowedHost(obj.Spec.EventSink.URL, cfg.EventSinkAllowedHosts) {
+			allErrs = append(allErrs, field.Forbidden(
+				specPath.Child("eventSink", "url"),
+				fmt.Sprintf("host %q is not in the controller's allowed event sink hosts %v", u.Hostname(), cfg.EventSinkAllowedHosts),
+			))
+		}
+	}
+
+	var policyWarnings admission.Warnings
+	if v.EnforcePolicies && (oldObj == nil || policyRelevantChange(&oldObj.Spec, &obj.Spec)) {
+		violations, invalid, err := policy.Check(ctx, v.Client, obj)
+		if err != nil {
+			allErrs = append(allErrs, field.InternalError(specPath, err))
+		}
+		allErrs = append(allErrs, violations...)
+		for _, err := range invalid {
+			policyWarnings = append(policyWarnings, fmt.Sprintf("%s, the policy is not enforced", err))
+		}
 	}
 
 	if err := controller.ValidateJobTemplate(ctx, v.Client, obj.Namespace, obj.Spec.JobTemplate); err != nil {
-		return nil, field.Invalid(
-			field.NewPath("spec").Child("jobTemplate"),
+		allErrs = append(allErrs, field.Invalid(
+			specPath.Child("jobTemplate"),
 			"<invalid>",
 			err.Error(),
-		)
+		))
 	}
 
-	return nil, nil
+	warnings := append(v.specWarnings(obj), policyWarnings...)
+	if len(allErrs) > 0 {
+		return warnings, apierrors.NewInvalid(triggersv1alpha.GroupVersion.WithKind("ChangeTriggeredJob").GroupKind(), obj.Name, allErrs)
+	}
+
+	return append(warnings, v.fieldWarnings(ctx, obj)...), nil
+}
+
+// policyRelevantChange reports whether an update changes the spec beyond suspend, so metadata-only updates such as
+// the trigger and acknowledge annotations and suspending or resuming are admitted after a policy is tightened
+func policyRelevantChange(oldSpec, newSpec *triggersv1alpha.ChangeTriggeredJobSpec) bool {
+	oldSpec, newSpec = oldSpec.DeepCopy(), newSpec.DeepCopy()
+	oldSpec.Suspend, newSpec.Suspend = nil, nil
+	return !equality.Semantic.DeepEqual(oldSpec, newSpec)
+}
+
+// validateResources validates every watched resource reference, its field expressions and context fields
+func (v *ChangeTriggeredJobCustomValidator) validateResources(ctx context.Context, obj *triggersv1alpha.ChangeTriggeredJob, existing []triggersv1alpha.ResourceReference) field.ErrorList {
+	resourcesPath := field.NewPath("spec", "resources")
+	if len(obj.Spec.Resources) == 0 {
+		return field.ErrorList{field.Invalid(
+			resourcesPath,
+			obj.Spec.Resources,
+			"at least one resource must be specified",
+		)}
+	}
+
+	// A namespace-scoped controller can only read resources in the namespaces it watches. ChangeTriggeredJobs in
+	// other namespaces belong to another controller instance and are left to its webhook.
+	restrictNamespaces := len(v.WatchNamespaces) > 0 && slices.Contains(v.WatchNamespaces, obj.Namespace)
+
+	var allErrs field.ErrorList
+	envs := map[string]bool{}
+	for i, ref := range obj.Spec.Resources {
+		gvk, err := controller.ValidateGVK(ctx, v.Mapper, ref.APIVersion, ref.Kind, ref.Namespace)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				resourcesPath.Index(i),
+				fmt.Sprintf("%s/%s", ref.APIVersion, ref.Kind),
+				err.Error(),
+			))
+		} else if restrictNamespaces && ref.Namespace == "" {
+			allErrs = append(allErrs, field.Forbidden(
+				resourcesPath.Index(i),
+				"cluster-scoped resources cannot be watched by a namespace-scoped controller",
+			))
+		} else if restrictNamespaces && !slices.Contains(v.WatchNamespaces, ref.Namespace) {
+			allErrs = append(allErrs, field.Forbidden(
+				resourcesPath.Index(i).Child("namespace"),
+				fmt.Sprintf("the controller only watches namespaces %s", strings.Join(v.WatchNamespaces, ", ")),
+			))
+		} else if !slices.ContainsFunc(existing, func(e triggersv1alpha.ResourceReference) bool { return sameResource(e, ref) }) {
+			if err := v.authorizeGet(ctx, *gvk, ref, resourcesPath.Index(i)); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+
+		for j, f := range ref.Fields {
+			if f == "*" {
+				continue
+			}
+			if _, err := controller.ParseFieldPath(f); err != nil {
+				allErrs = append(allErrs, field.Invalid(
+					resourcesPath.Index(i).Child("fields").Index(j),
+					f,
+					fmt.Sprintf("invalid JSONPath: %v", err),
+				))
+			}
+		}
+
+		for j, f := range ref.ContextFields {
+			contextPath := resourcesPath.Index(i).Child("contextFields").Index(j)
+			if _, err := controller.ParseFieldPath(f.Field); err != nil {
+				allErrs = append(allErrs, field.Invalid(
+					contextPath.Child("field"),
+					f.Field,
+					fmt.Sprintf("invalid JSONPath: %v", err),
+				))
+			}
+			for _, msg := range validation.IsEnvVarName(f.Env) {
+				allErrs = append(allErrs, field.Invalid(contextPath.Child("env"), f.Env, msg))
+			}
+			if envs[f.Env] {
+				allErrs = append(allErrs, field.Duplicate(contextPath.Child("env"), f.Env))
+			}
+			envs[f.Env] = true
+		}
+	}
+
+	return allErrs
+}
+
+// validateMinChanged checks that minChanged is set only for the AtLeast condition, and can be reached by the
+// weights of the watched resources
+func validateMinChanged(obj *triggersv1alpha.ChangeTriggeredJob) field.ErrorList {
+	minChangedPath := field.NewPath("spec", "minChanged")
+	var allErrs field.ErrorList
+	var total int32
+	for i, ref := range obj.Spec.Resources {
+		weight := ptr.Deref(ref.Weight, 1)
+		if weight < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				field.NewPath("spec", "resources").Index(i).Child("weight"),
+				weight,
+				"must be >= 0",
+			))
+		}
+		total += weight
+	}
+
+	if ptr.Deref(obj.Spec.Condition, "") != triggersv1alpha.TriggerConditionAtLeast {
+		if obj.Spec.MinChanged != nil {
+			allErrs = append(allErrs, field.Invalid(
+				minChangedPath,
+				*obj.Spec.MinChanged,
+				"only applies to the 'AtLeast' condition",
+			))
+		}
+		return allErrs
+	}
+
+	switch {
+	case obj.Spec.MinChanged == nil:
+		allErrs = append(allErrs, field.Required(minChangedPath, "required for the 'AtLeast' condition"))
+	case *obj.Spec.MinChanged < 1:
+		allErrs = append(allErrs, field.Invalid(minChangedPath, *obj.Spec.MinChanged, "must be >= 1"))
+	case *obj.Spec.MinChanged > total:
+		allErrs = append(allErrs, field.Invalid(
+			minChangedPath,
+			*obj.Spec.MinChanged,
+			fmt.Sprintf("exceeds the total weight %d of the watched resources, the condition can never be met", total),
+		))
+	}
+	return allErrs
+}
+
+// validateExpression checks the resource IDs, and that the trigger expression is set only for the Expression condition
+// and refers to those IDs
+func validateExpression(obj *triggersv1alpha.ChangeTriggeredJob) field.ErrorList {
+	resourcesPath := field.NewPath("spec", "resources")
+	expressionPath := field.NewPath("spec", "expression")
+	var allErrs field.ErrorList
+	seen := make(map[string]bool, len(obj.Spec.Resources))
+	for i, ref := range obj.Spec.Resources {
+		if ref.ID == "" {
+			continue
+		}
+		if !expression.IDPattern.MatchString(ref.ID) {
+			allErrs = append(allErrs, field.Invalid(
+				resourcesPath.Index(i).Child("id"),
+				ref.ID,
+				"must start with a letter or underscore and contain only letters, digits and underscores",
+			))
+		} else if seen[ref.ID] {
+			allErrs = append(allErrs, field.Duplicate(resourcesPath.Index(i).Child("id"), ref.ID))
+		}
+		seen[ref.ID] = true
+	}
+
+	if ptr.Deref(obj.Spec.Condition, "") != triggersv1alpha.TriggerConditionExpression {
+		if obj.Spec.Expression != "" {
+			allErrs = append(allErrs, field.Invalid(
+				expressionPath,
+				obj.Spec.Expression,
+				"only applies to the 'Expression' condition",
+			))
+		}
+		return allErrs
+	}
+
+	if obj.Spec.Expression == "" {
+		return append(allErrs, field.Required(expressionPath, "required for the 'Expression' condition"))
+	}
+	if _, err := controller.ParseTriggerExpression(&obj.Spec); err != nil {
+		allErrs = append(allErrs, field.Invalid(expressionPath, obj.Spec.Expression, err.Error()))
+	}
+	return allErrs
+}
+
+// authorizeGet runs a SubjectAccessReview as the requesting user, so users can only watch resources they can read
+// themselves instead of borrowing the controller's permissions
+func (v *ChangeTriggeredJobCustomValidator) authorizeGet(ctx context.Context, gvk schema.GroupVersionKind, ref triggersv1alpha.ResourceReference, path *field.Path) *field.Error {
+	if _, err := admission.RequestFromContext(ctx); err != nil {
+		// Not called through the admission webhook, there is no user to review
+		return nil
+	}
+
+	mapping, err := v.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return field.InternalError(path, err)
+	}
+
+	target := fmt.Sprintf("%s %q", mapping.Resource.GroupResource(), ref.Name)
+	if ref.Namespace != "" {
+		target = fmt.Sprintf("%s in namespace %q", target, ref.Namespace)
+	}
+	return v.authorize(ctx, &authorizationv1.ResourceAttributes{
+		Namespace: ref.Namespace,
+		Verb:      "get",
+		Group:     mapping.Resource.Group,
+		Version:   mapping.Resource.Version,
+		Resource:  mapping.Resource.Resource,
+		Name:      ref.Name,
+	}, path, "get "+target)
+}
+
+// authorizeServiceAccount runs a SubjectAccessReview as the requesting user, so users can only poll resources and
+// create jobs as a ServiceAccount they could impersonate themselves instead of borrowing the controller's permission
+func (v *ChangeTriggeredJobCustomValidator) authorizeServiceAccount(ctx context.Context, namespace, name string, path *field.Path) *field.Error {
+	return v.authorize(ctx, &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "impersonate",
+		Resource:  "serviceaccounts",
+		Name:      name,
+	}, path, fmt.Sprintf("impersonate serviceaccounts %q in namespace %q", name, namespace))
+}
+
+// authorize reviews whether the requesting user may perform the action described by attributes, allowing it when not
+// called through the admission webhook
+func (v *ChangeTriggeredJobCustomValidator) authorize(ctx context.Context, attributes *authorizationv1.ResourceAttributes, path *field.Path, action string) *field.Error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(req.UserInfo.Extra))
+	for k, val := range req.UserInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(val)
+	}
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               req.UserInfo.Username,
+			Groups:             req.UserInfo.Groups,
+			UID:                req.UserInfo.UID,
+			Extra:              extra,
+			ResourceAttributes: attributes,
+		},
+	}
+	if err := v.Client.Create(ctx, sar); err != nil {
+		return field.InternalError(path, fmt.Errorf("unable to review access: %w", err))
+	}
+
+	if !sar.Status.Allowed {
+		return field.Forbidden(path, fmt.Sprintf("user %q cannot %s", req.UserInfo.Username, action))
+	}
+
+	return nil
+}
+
+func sameResource(a, b triggersv1alpha.ResourceReference) bool {
+	return a.APIVersion == b.APIVersion && a.Kind == b.Kind && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// controllerConfig returns the reloadable controller configuration, or one with only PollInterval set without it
+func (v *ChangeTriggeredJobCustomValidator) controllerConfig() config.ControllerConfig {
+	if v.Config != nil {
+		return v.Config.Get()
+	}
+	return config.ControllerConfig{PollInterval: v.PollInterval}
+}
+
+// specWarnings reports legal but likely unintended configurations
+func (v *ChangeTriggeredJobCustomValidator) specWarnings(obj *triggersv1alpha.ChangeTriggeredJob) admission.Warnings {
+	var warnings admission.Warnings
+	specPath := field.NewPath("spec")
+
+	if len(v.WatchNamespaces) > 0 && !slices.Contains(v.WatchNamespaces, obj.Namespace) {
+		warnings = append(warnings, fmt.Sprintf("namespace %q is not watched by this controller, the ChangeTriggeredJob is not reconciled unless another instance watches it",
+			obj.Namespace))
+	}
+
+	cfg := v.controllerConfig()
+	var requested time.Duration
+	if obj.Spec.PollInterval != nil {
+		requested = obj.Spec.PollInterval.Duration
+	}
+	pollInterval := cfg.EffectivePollInterval(requested)
+	if requested > 0 && pollInterval != requested {
+		warnings = append(warnings, fmt.Sprintf("%s: %s is outside the controller bounds [%s, %s], polling every %s instead",
+			specPath.Child("pollInterval"), requested, cfg.MinPollInterval, cfg.MaxPollInterval, pollInterval))
+	}
+	if obj.Spec.Cooldown != nil && obj.Spec.Cooldown.Duration > 0 && obj.Spec.Cooldown.Duration < pollInterval {
+		warnings = append(warnings, fmt.Sprintf("%s: %s is shorter than the poll interval %s and has no effect",
+			specPath.Child("cooldown"), obj.Spec.Cooldown.Duration, pollInterval))
+	}
+
+	if window := obj.Spec.ChangeWindow; window != nil && window.Duration > 0 {
+		if !controller.AccumulatesChanges(&obj.Spec) {
+			warnings = append(warnings, fmt.Sprintf("%s: only applies to the 'All' condition and has no effect",
+				specPath.Child("changeWindow")))
+		} else if window.Duration < pollInterval {
+			warnings = append(warnings, fmt.Sprintf("%s: %s is shorter than the poll interval %s, changes in different polls never accumulate",
+				specPath.Child("changeWindow"), window.Duration, pollInterval))
+		}
+	}
+
+	// Jobs at least a cooldown apart never exceed window/cooldown+1 per window
+	if limit := obj.Spec.RateLimit; limit != nil && limit.Window.Duration > 0 && obj.Spec.Cooldown != nil && obj.Spec.Cooldown.Duration > 0 {
+		if perWindow := int64(limit.Window.Duration/obj.Spec.Cooldown.Duration) + 1; int64(limit.MaxTriggers) >= perWindow {
+			warnings = append(warnings, fmt.Sprintf("%s: the cooldown %s allows at most %d jobs per %s, the budget of %d is never exhausted",
+				specPath.Child("rateLimit", "maxTriggers"), obj.Spec.Cooldown.Duration, perWindow, limit.Window.Duration, limit.MaxTriggers))
+		}
+	}
+
+	// Changes are detected once per poll, at most window/pollInterval+1 per window
+	if detection := obj.Spec.FlappingDetection; detection != nil && detection.Window.Duration > 0 && pollInterval > 0 {
+		if perWindow := int64(detection.Window.Duration/pollInterval) + 1; int64(detection.Threshold) > perWindow {
+			warnings = append(warnings, fmt.Sprintf("%s: the poll interval %s detects at most %d changes per %s, the threshold of %d is never reached",
+				specPath.Child("flappingDetection", "threshold"), pollInterval, perWindow, detection.Window.Duration, detection.Threshold))
+		}
+	}
+
+	if obj.Spec.StartingDeadlineSeconds != nil && obj.Spec.Schedule == "" {
+		warnings = append(warnings, fmt.Sprintf("%s: only applies with a schedule and has no effect",
+			specPath.Child("startingDeadlineSeconds")))
+	}
+
+	seen := make(map[string]int, len(obj.Spec.Resources))
+	for i, ref := range obj.Spec.Resources {
+		if ptr.Deref(ref.FieldCondition, triggersv1alpha.FieldConditionAny) == triggersv1alpha.FieldConditionAll && len(ref.Fields) < 2 {
+			warnings = append(warnings, fmt.Sprintf("%s: 'All' has no effect with a single watched field",
+				specPath.Child("resources").Index(i).Child("fieldCondition")))
+		}
+		if len(ref.ContextFields) > 0 && ref.APIVersion == "v1" && ref.Kind == "Secret" {
+			warnings = append(warnings, fmt.Sprintf("%s: Secret values are copied into the Job spec, readable by anyone who can read Jobs",
+				specPath.Child("resources").Index(i).Child("contextFields")))
+		}
+
+		key := fmt.Sprintf("%s/%s/%s/%s", ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+		if first, ok := seen[key]; ok {
+			warnings = append(warnings, fmt.Sprintf("%s: duplicates %s, the resource is polled twice",
+				specPath.Child("resources").Index(i), specPath.Child("resources").Index(first)))
+			continue
+		}
+		seen[key] = i
+	}
+
+	return warnings
+}
+
+// fieldWarnings reports watched fields that do not currently resolve against the live objects
+func (v *ChangeTriggeredJobCustomValidator) fieldWarnings(ctx context.Context, obj *triggersv1alpha.ChangeTriggeredJob) admission.Warnings {
+	if v.Config != nil && !v.Config.Get().Enabled(config.FeatureFieldWarnings) {
+		return nil
+	}
+
+	reader := v.Reader
+	if reader == nil {
+		reader = v.Client
+	}
+
+	var warnings admission.Warnings
+	for i, ref := range obj.Spec.Resources {
+		path := field.NewPath("spec", "resources").Index(i)
+
+		live := &unstructured.Unstructured{}
+		live.SetAPIVersion(ref.APIVersion)
+		live.SetKind(ref.Kind)
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, live); err != nil {
+			if apierrors.IsNotFound(err) {
+				warnings = append(warnings, fmt.Sprintf("%s: %s %q does not exist yet", path, ref.Kind, ref.Name))
+			} else {
+				log.V(1).Info("Unable to fetch watched resource", "resource", path.String(), "error", err.Error())
+			}
+			continue
+		}
+
+		for j, f := range ref.Fields {
+			if f == "*" {
+				continue
+			}
+			values, err := controller.ExtractField(live.Object, f)
+			if err != nil || len(values) == 0 {
+				warnings = append(warnings, fmt.Sprintf("%s: %q does not match anything in %s %q", path.Child("fields").Index(j), f, ref.Kind, ref.Name))
+			}
+		}
+	}
+
+	return warnings
 }
 
 // ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type ChangeTriggeredJob.
 func (v *ChangeTriggeredJobCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj *triggersv1alpha.ChangeTriggeredJob) (admission.Warnings, error) {
 	log.Info("Validation for ChangeTriggeredJob upon update", "name", newObj.GetName())
-	return v.ValidateCreate(ctx, newObj)
+	return v.validate(ctx, newObj, oldObj)
 }
 
 // ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type ChangeTriggeredJob.
@@ -22,14 +22,19 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/config"
 	// TODO (user): Add any additional imports if needed
 )
 
@@ -884,6 +889,194 @@ var _ = Describe("ChangeTriggeredJob Webhook", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("Should validate the AtLeast condition threshold", func() {
+			By("Creating a ChangeTriggeredJob requiring 2 of 2 weighted resources")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+					Required:   true,
+				},
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName + "-2",
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAtLeast)
+			obj.Spec.MinChanged = ptr.To[int32](2)
+
+			By("Expecting no validation error")
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Expecting an error when minChanged exceeds the total weight")
+			obj.Spec.MinChanged = ptr.To[int32](3)
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("exceeds the total weight 2"))
+
+			By("Expecting no error once a resource weighs more")
+			obj.Spec.Resources[1].Weight = ptr.To[int32](2)
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Expecting an error when minChanged is missing")
+			obj.Spec.MinChanged = nil
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.minChanged: Required value"))
+
+			By("Expecting an error when minChanged is set for another condition")
+			obj.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAny)
+			obj.Spec.MinChanged = ptr.To[int32](1)
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("only applies to the 'AtLeast' condition"))
+		})
+
+		It("Should validate trigger expressions", func() {
+			By("Creating a ChangeTriggeredJob with an expression over named resources")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					ID:         "dbConfig",
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+				{
+					ID:         "featureFlags",
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName + "-2",
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionExpression)
+			obj.Spec.Expression = "dbConfig && (featureFlags || !dbConfig)"
+
+			By("Expecting no validation error")
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Expecting an error when the expression refers to an unknown ID")
+			obj.Spec.Expression = "dbConfig || secrets"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("refers to unknown resource IDs secrets"))
+
+			By("Expecting an error when the expression cannot be parsed")
+			obj.Spec.Expression = "dbConfig &&"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unexpected end of expression"))
+
+			By("Expecting an error when two resources share an ID")
+			obj.Spec.Expression = "dbConfig"
+			obj.Spec.Resources[1].ID = "dbConfig"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.resources[1].id: Duplicate value"))
+
+			By("Expecting an error when the expression is missing")
+			obj.Spec.Resources[1].ID = "featureFlags"
+			obj.Spec.Expression = ""
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.expression: Required value"))
+
+			By("Expecting an error when the expression is set for another condition")
+			obj.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAny)
+			obj.Spec.Expression = "dbConfig"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("only applies to the 'Expression' condition"))
+		})
+
+		It("Should validate context fields and warn about the field condition", func() {
+			By("Creating a ChangeTriggeredJob with context fields")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion:     "v1",
+					Kind:           testKindConfigMap,
+					Name:           testCMName,
+					Namespace:      testNamespace,
+					Fields:         []string{"data.key"},
+					FieldCondition: ptr.To(triggersv1alpha.FieldConditionAll),
+					ContextFields: []triggersv1alpha.ContextField{
+						{Field: "data.version", Env: "APP_VERSION"},
+						{Field: "metadata.labels", Env: "APP_LABELS"},
+					},
+				},
+			}
+
+			By("Expecting admission with a warning about the field condition")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.resources[0].fieldCondition: 'All' has no effect with a single watched field")))
+
+			By("Expecting errors for an invalid JSONPath, an invalid and a duplicate variable name")
+			obj.Spec.Resources[0].ContextFields = []triggersv1alpha.ContextField{
+				{Field: "data[", Env: "APP_VERSION"},
+				{Field: "data.version", Env: "1APP"},
+				{Field: "data.other", Env: "APP_VERSION"},
+			}
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.resources[0].contextFields[0].field"))
+			Expect(err.Error()).To(ContainSubstring("spec.resources[0].contextFields[1].env"))
+			Expect(err.Error()).To(ContainSubstring("spec.resources[0].contextFields[2].env: Duplicate value"))
+		})
+
 		It("Should deny negative history values", func() {
 			By("Creating a ChangeTriggeredJob with negative history")
 			obj.Spec.History = new(int32(-1))
@@ -1405,6 +1598,781 @@ var _ = Describe("ChangeTriggeredJob Webhook", func() {
 			By("Expecting no validation error")
 			Expect(err).NotTo(HaveOccurred())
 		})
+
+		It("Should deny creation with a non-HTTP event sink URL", func() {
+			By("Creating a ChangeTriggeredJob with an invalid event sink")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.EventSink = &triggersv1alpha.EventSink{URL: "broker.default.svc/events"}
+
+			By("Calling ValidateCreate")
+			_, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting validation error")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.eventSink.url"))
+
+			By("Using an absolute URL to a host the controller does not allow")
+			obj.Spec.EventSink.URL = "http://broker.default.svc/events"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("allowed event sink hosts"))
+
+			By("Allowing the host in the controller configuration")
+			cfg := config.DefaultControllerConfig
+			cfg.EventSinkAllowedHosts = []string{"*.default.svc"}
+			validator.Config = config.NewStore(cfg)
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny creation with invalid JSONPath in fields", func() {
+			By("Creating a ChangeTriggeredJob with a malformed field expression")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+					Fields:     []string{"data.key1", "data.key2[0"},
+				},
+			}
+
+			By("Calling ValidateCreate")
+			_, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting the error to point at the invalid field")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.resources[0].fields[1]"))
+		})
+
+		It("Should warn when a field does not resolve against the live object", func() {
+			By("Creating the watched ConfigMap")
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "test-cm-",
+					Namespace:    testNamespace,
+				},
+				Data: map[string]string{"key1": "value1"},
+			}
+			Expect(k8sClient.Create(ctx, cm)).To(Succeed())
+			defer func() {
+				Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+			}()
+
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       cm.Name,
+					Namespace:  testNamespace,
+					Fields:     []string{"data.key1", "data.kye2"},
+				},
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       "does-not-exist",
+					Namespace:  testNamespace,
+				},
+			}
+
+			By("Calling ValidateCreate")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting admission with warnings")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(2))
+			Expect(warnings[0]).To(ContainSubstring("spec.resources[0].fields[1]"))
+			Expect(warnings[1]).To(ContainSubstring("does not exist"))
+		})
+
+		It("Should report every validation error at once", func() {
+			By("Creating a ChangeTriggeredJob with several invalid fields")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       "NonExistentKind",
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+					Fields:     []string{"data["},
+				},
+			}
+			obj.Spec.Condition = ptr.To(triggersv1alpha.TriggerCondition("Invalid"))
+			obj.Spec.History = new(int32(0))
+			obj.Spec.Cooldown = &metav1.Duration{Duration: -1 * time.Second}
+
+			By("Calling ValidateCreate")
+			_, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting a single aggregated Invalid error")
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			statusErr, ok := err.(apierrors.APIStatus)
+			Expect(ok).To(BeTrue())
+			fields := []string{}
+			for _, cause := range statusErr.Status().Details.Causes {
+				fields = append(fields, cause.Field)
+			}
+			Expect(fields).To(ConsistOf(
+				"spec.resources[0]",
+				"spec.resources[1].fields[0]",
+				"spec.condition",
+				"spec.history",
+				"spec.cooldown",
+			))
+		})
+
+		It("Should warn about suspicious but legal configurations", func() {
+			By("Creating a ChangeTriggeredJob with a short cooldown and a duplicate resource")
+			validator.PollInterval = time.Minute
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			ref := triggersv1alpha.ResourceReference{
+				APIVersion: "v1",
+				Kind:       testKindConfigMap,
+				Name:       testCMName,
+				Namespace:  testNamespace,
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{ref, ref}
+			obj.Spec.Cooldown = &metav1.Duration{Duration: 10 * time.Second}
+
+			By("Calling ValidateCreate")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting admission with warnings")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.cooldown")))
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.resources[1]: duplicates spec.resources[0]")))
+		})
+
+		It("Should validate the change window", func() {
+			By("Creating a ChangeTriggeredJob with the Any condition and a change window")
+			validator.PollInterval = time.Minute
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAny)
+			obj.Spec.ChangeWindow = &metav1.Duration{Duration: 30 * time.Second}
+
+			By("Expecting a warning that it has no effect")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.changeWindow: only applies to the 'All' condition")))
+
+			By("Expecting a warning when it is shorter than the poll interval")
+			obj.Spec.Condition = ptr.To(triggersv1alpha.TriggerConditionAll)
+			warnings, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("shorter than the poll interval 1m0s, changes in different polls never accumulate")))
+
+			By("Expecting an error for a negative window")
+			obj.Spec.ChangeWindow = &metav1.Duration{Duration: -time.Minute}
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.changeWindow"))
+		})
+
+		It("Should deny references the requesting user cannot read", func() {
+			By("Creating a ChangeTriggeredJob watching a Secret")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       "Secret",
+					Name:       "db-credentials",
+					Namespace:  testNamespace,
+				},
+			}
+
+			By("Validating as a user without access to the Secret")
+			unprivileged := admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: "tenant"},
+				},
+			})
+			_, err := validator.ValidateCreate(unprivileged, obj)
+
+			By("Expecting a Forbidden cause on the reference")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.resources[0]: Forbidden"))
+			Expect(err.Error()).To(ContainSubstring(`user "tenant" cannot get secrets "db-credentials"`))
+
+			By("Validating as a cluster admin")
+			admin := admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}},
+				},
+			})
+			_, err = validator.ValidateCreate(admin, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Updating without adding references as the user without access")
+			_, err = validator.ValidateUpdate(unprivileged, obj.DeepCopy(), obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny creation with an invalid ServiceAccount name", func() {
+			By("Creating a ChangeTriggeredJob with a malformed serviceAccountName")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.ServiceAccountName = "Job_Runner"
+
+			By("Calling ValidateCreate")
+			_, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting validation error")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.serviceAccountName"))
+
+			By("Using a valid name")
+			obj.Spec.ServiceAccountName = "job-runner"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny a ServiceAccount the requesting user cannot impersonate", func() {
+			By("Creating a ChangeTriggeredJob running as a ServiceAccount")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.ServiceAccountName = "job-runner"
+
+			By("Validating as a user who cannot impersonate the ServiceAccount")
+			unprivileged := admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: "tenant"},
+				},
+			})
+			_, err := validator.ValidateCreate(unprivileged, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.serviceAccountName: Forbidden"))
+			Expect(err.Error()).To(ContainSubstring(`user "tenant" cannot impersonate serviceaccounts "job-runner" in namespace "default"`))
+
+			By("Validating as a cluster admin")
+			admin := admission.NewContextWithRequest(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					UserInfo: authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}},
+				},
+			})
+			_, err = validator.ValidateCreate(admin, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Updating without changing the ServiceAccount as the user who cannot impersonate it")
+			_, err = validator.ValidateUpdate(unprivileged, obj.DeepCopy(), obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Changing the ServiceAccount as the user who cannot impersonate it")
+			updated := obj.DeepCopy()
+			updated.Spec.ServiceAccountName = "cluster-admin"
+			_, err = validator.ValidateUpdate(unprivileged, obj, updated)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`cannot impersonate serviceaccounts "cluster-admin"`))
+		})
+
+		It("Should deny references outside the watched namespaces", func() {
+			By("Restricting the controller to the ChangeTriggeredJob's namespace")
+			validator.WatchNamespaces = []string{testNamespace}
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  "kube-system",
+				},
+				{
+					APIVersion: "v1",
+					Kind:       "Namespace",
+					Name:       testNamespace,
+				},
+			}
+
+			By("Calling ValidateCreate")
+			_, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting the cross-namespace and cluster-scoped references to be forbidden")
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			statusErr, ok := err.(apierrors.APIStatus)
+			Expect(ok).To(BeTrue())
+			fields := []string{}
+			for _, cause := range statusErr.Status().Details.Causes {
+				fields = append(fields, cause.Field)
+			}
+			Expect(fields).To(ConsistOf("spec.resources[1].namespace", "spec.resources[2]"))
+
+			By("Warning instead when the ChangeTriggeredJob is outside the watched namespaces")
+			validator.WatchNamespaces = []string{"team-a"}
+			obj.Spec.Resources = obj.Spec.Resources[:1]
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("is not watched by this controller")))
+		})
+
+		It("Should deny ChangeTriggeredJobs violating a ChangeJobPolicy", func() {
+			By("Creating a policy restricting watched kinds")
+			policy := &triggersv1alpha.ChangeJobPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook-test-policy"},
+				Spec: triggersv1alpha.ChangeJobPolicySpec{
+					AllowedKinds: []triggersv1alpha.PolicyGroupKind{{Group: "", Kind: "Secret"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).Should(Succeed())
+			defer func() { _ = k8sClient.Delete(ctx, policy) }()
+
+			validator.EnforcePolicies = true
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+
+			By("Calling ValidateCreate")
+			_, err := validator.ValidateCreate(ctx, obj)
+
+			By("Expecting the policy violation")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ChangeJobPolicy webhook-test-policy does not allow watching ConfigMap"))
+
+			By("Admitting metadata-only and suspend updates of an existing ChangeTriggeredJob")
+			oldObj = obj.DeepCopy()
+			obj.Annotations = map[string]string{triggersv1alpha.TriggerRequestedAtAnnotation: "2025-06-01T12:00:00Z"}
+			obj.Spec.Suspend = ptr.To(true)
+			_, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Enforcing the policy when the spec changes")
+			obj.Spec.Resources[0].Fields = []string{"data"}
+			_, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ChangeJobPolicy webhook-test-policy does not allow watching ConfigMap"))
+
+			By("Ignoring policies when enforcement is disabled")
+			validator.EnforcePolicies = false
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should validate and warn about the poll interval", func() {
+			By("Creating a ChangeTriggeredJob with a poll interval below the controller minimum")
+			validator.Config = config.NewStore(config.DefaultControllerConfig)
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.PollInterval = &metav1.Duration{Duration: time.Second}
+			obj.Spec.Cooldown = &metav1.Duration{Duration: 5 * time.Second}
+
+			By("Expecting admission with warnings against the clamped interval")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.pollInterval: 1s is outside the controller bounds")))
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.cooldown: 5s is shorter than the poll interval 10s")))
+
+			By("Expecting a non-positive poll interval to be rejected")
+			obj.Spec.PollInterval = &metav1.Duration{Duration: 0}
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.pollInterval"))
+		})
+
+		It("Should validate trigger windows", func() {
+			By("Creating a ChangeTriggeredJob with a weekday maintenance window")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.TriggerWindows = []triggersv1alpha.TriggerWindow{
+				{Days: []triggersv1alpha.Weekday{"Sat", "Sun"}, Start: "22:00", End: "02:00", TimeZone: "Europe/Berlin"},
+			}
+
+			By("Expecting no validation error")
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Expecting errors for an unknown time zone and an invalid time")
+			obj.Spec.TriggerWindows = append(obj.Spec.TriggerWindows,
+				triggersv1alpha.TriggerWindow{Start: "22:00", End: "02:00", TimeZone: "Mars/Olympus"},
+				triggersv1alpha.TriggerWindow{Start: "24:00", End: "02:00"},
+			)
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`spec.triggerWindows[1]`))
+			Expect(err.Error()).To(ContainSubstring(`unknown time zone "Mars/Olympus"`))
+			Expect(err.Error()).To(ContainSubstring(`start "24:00" must be a time of day HH:MM`))
+		})
+
+		It("Should validate the schedule", func() {
+			By("Creating a ChangeTriggeredJob with a starting deadline but no schedule")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.StartingDeadlineSeconds = ptr.To[int64](300)
+
+			By("Expecting a warning that the deadline has no effect")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.startingDeadlineSeconds: only applies with a schedule")))
+
+			By("Expecting no warning with a schedule in a time zone")
+			obj.Spec.Schedule = "CRON_TZ=Europe/Berlin 0 3 * * *"
+			warnings, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).NotTo(ContainElement(ContainSubstring("spec.startingDeadlineSeconds")))
+
+			By("Expecting an error for an invalid schedule")
+			obj.Spec.Schedule = "0 3 * *"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.schedule"))
+			Expect(err.Error()).To(ContainSubstring("expected exactly 5 fields"))
+		})
+
+		It("Should validate the rate limit", func() {
+			By("Creating a ChangeTriggeredJob limited to 5 jobs per hour")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.Cooldown = &metav1.Duration{Duration: time.Minute}
+			obj.Spec.RateLimit = &triggersv1alpha.TriggerRateLimit{MaxTriggers: 5, Window: metav1.Duration{Duration: time.Hour}}
+
+			By("Expecting no validation error or warning")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).NotTo(ContainElement(ContainSubstring("spec.rateLimit")))
+
+			By("Expecting a warning when the cooldown never lets the budget run out")
+			obj.Spec.Cooldown = &metav1.Duration{Duration: 30 * time.Minute}
+			warnings, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.rateLimit.maxTriggers: the cooldown 30m0s allows at most 3 jobs per 1h0m0s")))
+
+			By("Expecting an error for an empty window")
+			obj.Spec.RateLimit.Window = metav1.Duration{}
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.rateLimit.window"))
+		})
+
+		It("Should validate flapping detection", func() {
+			By("Creating a ChangeTriggeredJob detecting 5 changes in 10 minutes")
+			obj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  testContainerName,
+									Image: testContainerImage,
+								},
+							},
+							RestartPolicy: corev1.RestartPolicyNever,
+						},
+					},
+				},
+			}
+			obj.Spec.Resources = []triggersv1alpha.ResourceReference{
+				{
+					APIVersion: "v1",
+					Kind:       testKindConfigMap,
+					Name:       testCMName,
+					Namespace:  testNamespace,
+				},
+			}
+			obj.Spec.PollInterval = &metav1.Duration{Duration: time.Minute}
+			obj.Spec.FlappingDetection = &triggersv1alpha.FlappingDetection{Threshold: 5, Window: metav1.Duration{Duration: 10 * time.Minute}, AutoSuspend: true}
+
+			By("Expecting no validation error or warning")
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).NotTo(ContainElement(ContainSubstring("spec.flappingDetection")))
+
+			By("Expecting a warning when the poll interval never detects enough changes")
+			obj.Spec.PollInterval = &metav1.Duration{Duration: 5 * time.Minute}
+			warnings, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.flappingDetection.threshold: the poll interval 5m0s detects at most 3 changes per 10m0s")))
+
+			By("Expecting an error for an empty window")
+			obj.Spec.FlappingDetection.Window = metav1.Duration{}
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.flappingDetection.window"))
+		})
 	})
 
 })
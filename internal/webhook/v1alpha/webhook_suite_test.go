@@ -40,6 +40,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/internal/config"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -109,7 +110,10 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
-	err = SetupChangeTriggeredJobWebhookWithManager(mgr)
+	err = SetupChangeTriggeredJobWebhookWithManager(mgr, config.NewStore(config.DefaultControllerConfig))
+	Expect(err).NotTo(HaveOccurred())
+
+	err = SetupChangeJobPolicyWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
 	// +kubebuilder:scaffold:webhook
@@ -1007,23 +1007,15 @@ var _ = Describe("E2E Tests", Ordered, func() {
 				}
 				Expect(ownedJobs).To(BeEmpty(), "No job should be created until all resources change")
 
-				By("updating both ConfigMap and Secret together for All condition")
-				// For "All" condition, both resources must change within the same reconciliation window
-				// Update both resources close together so controller sees both changes in same cycle
-				err = k8sClient.Get(ctx, types.NamespacedName{Name: "all-configmap", Namespace: testNamespace}, cm)
-				Expect(err).NotTo(HaveOccurred())
-				cm.Data["key1"] = "value3"
-				err = k8sClient.Update(ctx, cm)
-				Expect(err).NotTo(HaveOccurred())
-
-				// Update secret immediately after
+				By("updating the Secret in a later poll for All condition")
+				// The ConfigMap change is accumulated until a job triggers, so the Secret change completes the condition
 				err = k8sClient.Get(ctx, types.NamespacedName{Name: "all-secret", Namespace: testNamespace}, secret)
 				Expect(err).NotTo(HaveOccurred())
 				secret.Data["password"] = []byte("newpassword")
 				err = k8sClient.Update(ctx, secret)
 				Expect(err).NotTo(HaveOccurred())
 
-				By("verifying that a job was triggered after all resources changed together")
+				By("verifying that a job was triggered after all resources changed")
 				Eventually(func(g Gomega) {
 					jobList := &batchv1.JobList{}
 					err := k8sClient.List(ctx, jobList, client.InNamespace(testNamespace))
@@ -1038,7 +1030,7 @@ var _ = Describe("E2E Tests", Ordered, func() {
 							}
 						}
 					}
-					g.Expect(ownedJobs).NotTo(BeEmpty(), "Job should be created when all resources change together")
+					g.Expect(ownedJobs).NotTo(BeEmpty(), "Job should be created once all resources changed")
 				}, 60*time.Second, 3*time.Second).Should(Succeed())
 			})
 		})
@@ -0,0 +1,159 @@
+//go:build e2e
+// +build e2e
+
+/*
+Copyright 2025 Bowen Sun.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	triggersv1alpha "github.com/nusnewob/kube-changejob/api/v1alpha"
+	"github.com/nusnewob/kube-changejob/test/utils"
+)
+
+// namespacedRelease is the Helm release installed with rbac.namespaced=true
+const namespacedRelease = "changejob-namespaced"
+
+// namespacedNamespace is the release namespace, and the only namespace the namespaced controller watches
+const namespacedNamespace = "kube-changejob-namespaced"
+
+// namespacedServiceAccount is the manager ServiceAccount created by the namespaced release
+const namespacedServiceAccount = namespacedRelease + "-kube-changejob-controller-manager"
+
+var _ = Describe("Namespace-Scoped Installation", Ordered, func() {
+	var c client.Client
+
+	BeforeAll(func() {
+		var err error
+
+		By("setting up Kubernetes client")
+		c, err = client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme.Scheme})
+		Expect(err).NotTo(HaveOccurred(), "Failed to create Kubernetes client")
+
+		if !utils.IsCertManagerCRDsInstalled() {
+			By("installing cert-manager")
+			Expect(utils.InstallCertManager()).To(Succeed(), "Failed to install cert-manager")
+		}
+
+		By("installing the chart with namespaced RBAC")
+		cmd := exec.Command("make", "helm-deploy",
+			fmt.Sprintf("IMG=%s", managerImage),
+			fmt.Sprintf("HELM_RELEASE=%s", namespacedRelease),
+			fmt.Sprintf("HELM_NAMESPACE=%s", namespacedNamespace),
+			"HELM_EXTRA_ARGS=--set rbac.namespaced=true --set manager.args[0]=--leader-elect --set manager.args[1]=--poll-interval=10s")
+		_, err = utils.Run(cmd)
+		Expect(err).NotTo(HaveOccurred(), "Failed to install the chart")
+	})
+
+	AfterAll(func() {
+		By("uninstalling the chart")
+		cmd := exec.Command("make", "helm-uninstall",
+			fmt.Sprintf("HELM_RELEASE=%s", namespacedRelease),
+			fmt.Sprintf("HELM_NAMESPACE=%s", namespacedNamespace))
+		_, _ = utils.Run(cmd)
+
+		By("removing the release namespace")
+		_ = c.Delete(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespacedNamespace}})
+	})
+
+	It("should grant the cluster-scoped permissions through a ClusterRole", func() {
+		user := fmt.Sprintf("--as=system:serviceaccount:%s:%s", namespacedNamespace, namespacedServiceAccount)
+		for _, access := range [][]string{
+			{"create", "subjectaccessreviews.authorization.k8s.io"},
+			{"list", "namespaces"},
+			{"impersonate", "groups"},
+		} {
+			cmd := exec.Command("kubectl", "auth", "can-i", access[0], access[1], user)
+			output, err := utils.Run(cmd)
+			Expect(err).NotTo(HaveOccurred(), "Expected the manager to %s %s", access[0], access[1])
+			Expect(strings.TrimSpace(output)).To(Equal("yes"))
+		}
+	})
+
+	It("should admit a ChangeTriggeredJob and trigger a job on change", func() {
+		ctx := context.Background()
+
+		By("creating a ConfigMap")
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "namespaced-configmap", Namespace: namespacedNamespace},
+			Data:       map[string]string{"key1": "value1"},
+		}
+		Expect(c.Create(ctx, cm)).To(Succeed())
+
+		By("creating a ChangeTriggeredJob, which the webhook admits only if its access check can run")
+		ctj := &triggersv1alpha.ChangeTriggeredJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "namespaced-change-job", Namespace: namespacedNamespace},
+			Spec: triggersv1alpha.ChangeTriggeredJobSpec{
+				Resources: []triggersv1alpha.ResourceReference{
+					{APIVersion: "v1", Kind: "ConfigMap", Name: cm.Name, Namespace: namespacedNamespace},
+				},
+				JobTemplate: batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers:    []corev1.Container{{Name: "test", Image: "busybox", Command: []string{"echo", "ConfigMap changed!"}}},
+								RestartPolicy: corev1.RestartPolicyNever,
+							},
+						},
+					},
+				},
+			},
+		}
+		Eventually(func() error {
+			err := c.Create(ctx, ctj)
+			if apierrors.IsAlreadyExists(err) {
+				return nil
+			}
+			return err
+		}, 2*time.Minute, 3*time.Second).Should(Succeed())
+
+		By("waiting for the baseline to be recorded")
+		Eventually(func(g Gomega) {
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(ctj), ctj)).To(Succeed())
+			g.Expect(ctj.Status.ResourceHashes).NotTo(BeEmpty())
+		}, 2*time.Minute, 3*time.Second).Should(Succeed())
+
+		By("updating the ConfigMap")
+		Expect(c.Get(ctx, types.NamespacedName{Name: cm.Name, Namespace: namespacedNamespace}, cm)).To(Succeed())
+		cm.Data["key1"] = "value2"
+		Expect(c.Update(ctx, cm)).To(Succeed())
+
+		By("verifying that a job was created")
+		Eventually(func(g Gomega) {
+			jobList := &batchv1.JobList{}
+			g.Expect(c.List(ctx, jobList, client.InNamespace(namespacedNamespace))).To(Succeed())
+			g.Expect(jobList.Items).NotTo(BeEmpty(), "Expected at least one job to be created")
+		}, time.Minute, 3*time.Second).Should(Succeed())
+	})
+})